@@ -11,7 +11,7 @@ import (
 )
 
 func main() {
-	log := slog.New(cslog.NewCustomHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	log := slog.New(cslog.NewTraceHandler(cslog.NewCustomHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))))
 	application := app.MustNew(log)
 
 	go application.MustRun()
@@ -0,0 +1,54 @@
+// Command rotate-keys creates a new customer PII data key and
+// re-encrypts customers with it. It runs next to the service and uses
+// the same config.
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"user-service/internal/app"
+	"user-service/internal/config"
+	cslog "user-service/internal/lib/slog"
+	"user-service/internal/service/keyrotation"
+	"user-service/internal/storage/psql"
+	customerRepo "user-service/internal/storage/repository/customer"
+)
+
+func main() {
+	log := slog.New(cslog.NewTraceHandler(cslog.NewCustomHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	cfg := config.MustLoad()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	if err := run(ctx, cfg, log); err != nil {
+		log.Error("key rotation failed", slog.String("error", err.Error()))
+		stop()
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, log *slog.Logger) error {
+	if !cfg.Encryption.Enabled {
+		return errors.New("encryption is disabled, nothing to rotate")
+	}
+
+	storage, err := psql.Init(ctx, cfg.Postgres, log)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	codec, err := app.NewCodec(cfg, storage)
+	if err != nil {
+		return err
+	}
+
+	svc := keyrotation.New(log, customerRepo.New(storage, cfg.Postgres.QueryTimeout, codec), codec, cfg.Encryption)
+	return svc.Run(ctx)
+}
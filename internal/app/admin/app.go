@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// App admin HTTP server, separated from public API port.
+type App struct {
+	log        *slog.Logger
+	httpServer *http.Server
+}
+
+func New(
+	log *slog.Logger,
+	port string,
+	metricsPath string,
+) *App {
+	r := chi.NewRouter()
+
+	r.Handle(metricsPath, promhttp.Handler())
+
+	httpServer := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+
+	return &App{
+		log:        log,
+		httpServer: httpServer,
+	}
+}
+
+func (a *App) Run() error {
+	const op = "app.admin.Run"
+	a.log.With(slog.String("op", op)).Info("starting admin server", "port", a.httpServer.Addr)
+	if err := a.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (a *App) Stop(ctx context.Context) error {
+	const op = "app.admin.Stop"
+	a.log.With(slog.String("op", op)).Info("stopping admin server")
+	return a.httpServer.Shutdown(ctx)
+}
@@ -2,68 +2,392 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"os"
+	"sync"
 	"time"
+	"user-service/internal/app/admin"
 	"user-service/internal/app/rest"
 	"user-service/internal/config"
+	mw "user-service/internal/http/middleware"
+	"user-service/internal/lib/health"
+	"user-service/internal/lib/metrics"
 	"user-service/internal/lib/migrator"
+	"user-service/internal/lib/ratelimit"
+	cslog "user-service/internal/lib/slog"
+	"user-service/internal/lib/tlsutil"
+	"user-service/internal/lib/tracing"
+	attributesService "user-service/internal/service/attributes"
+	consentService "user-service/internal/service/consent"
 	customerService "user-service/internal/service/customer"
+	dsarService "user-service/internal/service/dsar"
+	gendersService "user-service/internal/service/genders"
+	greetingsService "user-service/internal/service/greetings"
+	importsService "user-service/internal/service/imports"
+	outboxService "user-service/internal/service/outbox"
+	preferencesService "user-service/internal/service/preferences"
+	schedulerService "user-service/internal/service/scheduler"
+	segmentService "user-service/internal/service/segment"
+	tagsService "user-service/internal/service/tags"
 	"user-service/internal/storage/psql"
+	"user-service/internal/storage/redis"
+	attributesRepo "user-service/internal/storage/repository/attributes"
+	consentRepo "user-service/internal/storage/repository/consent"
 	customerRepo "user-service/internal/storage/repository/customer"
+	dsarRepo "user-service/internal/storage/repository/dsar"
+	gendersRepo "user-service/internal/storage/repository/genders"
+	greetingsRepo "user-service/internal/storage/repository/greetings"
+	idempotencyRepo "user-service/internal/storage/repository/idempotency"
+	importsRepo "user-service/internal/storage/repository/imports"
+	outboxRepo "user-service/internal/storage/repository/outbox"
+	preferencesRepo "user-service/internal/storage/repository/preferences"
+	segmentRepo "user-service/internal/storage/repository/segment"
+	tagsRepo "user-service/internal/storage/repository/tags"
 )
 
 type App struct {
-	log     *slog.Logger
-	storage *psql.Storage
-	restApp *rest.App
+	log      *slog.Logger
+	storage  *psql.Storage
+	redis    *redis.Storage
+	restApp  *rest.App
+	adminApp *admin.App
+	checker  *health.Checker
+	// serveErr receives the result of restApp.Run
+	serveErr chan error
+
+	drainDelay      time.Duration
+	shutdownTracing func(context.Context) error
+
+	// background workers, stopped by bgCancel on shutdown
+	workers   []func(ctx context.Context)
+	workersWg sync.WaitGroup
+	bgCtx     context.Context
+	bgCancel  context.CancelFunc
 }
 
+// MustNew creates application or exits with aggregated startup error.
 func MustNew(log *slog.Logger) *App {
-	cfg := config.MustLoad()
+	a, err := New(log)
+	if err != nil {
+		log.Error("application startup failed", "error", err)
+		os.Exit(1)
+	}
+	return a
+}
 
-	storage := psql.Init(cfg.Postgres)
+// New creates application. Dependencies are connected with retries,
+// all startup failures are joined into one error. Whatever was opened
+// before a failure is closed again.
+func New(log *slog.Logger) (_ *App, err error) {
+	const op = "app.New"
 
-	if err := migrator.RunMigrations(cfg.Postgres, log); err != nil {
-		log.Error("failed to run migrations", "error", err)
-		panic(err)
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	ctx := context.Background()
+
+	switch {
+	case cslog.Unredacted(cfg.Log):
+		log.Warn("log redaction is disabled, logs contain PII")
+	case cfg.Log.DebugUnredacted:
+		log.Warn("log.debug_unredacted ignored without -log-unredacted flag")
+		fallthrough
+	default:
+		log = slog.New(cslog.NewRedactHandler(log.Handler(), cslog.NewRedactPolicy(cfg.Log, cfg.SecretKey)))
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			shutdownTracing(ctx)
+		}
+	}()
+
+	checker := health.New(log, cfg.Health.CheckTimeout)
+
+	var tlsReloader *tlsutil.Reloader
+	if cfg.Server.TLS.Enabled {
+		tlsReloader, err = tlsutil.NewReloader(cfg.Server.TLS, log)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// probes are served while dependencies are connected and migrated,
+	// the API is mounted once startup is done
+	restApp := rest.New(log, checker, cfg, tlsReloader)
+	if err := restApp.Listen(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- restApp.Run()
+	}()
+	defer func() {
+		if err != nil {
+			restApp.Stop(ctx)
+		}
+	}()
+
+	var (
+		storage      *psql.Storage
+		redisStorage *redis.Storage
+		pgErr        error
+		redisErr     error
+		wg           sync.WaitGroup
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		storage, pgErr = psql.Init(ctx, cfg.Postgres, log)
+		if pgErr != nil {
+			return
+		}
+		pgErr = migrator.RunMigrations(ctx, cfg.Postgres, log)
+	}()
+
+	if cfg.Redis.Enabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			redisStorage, redisErr = redis.Init(ctx, cfg.Redis, log)
+		}()
+	}
+	wg.Wait()
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		if storage != nil {
+			storage.Close()
+		}
+		if redisStorage != nil {
+			redisStorage.Close()
+		}
+	}()
+	if err := errors.Join(pgErr, redisErr); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := metrics.RegisterDBStats(storage.GetDB().DB, cfg.Postgres.DbName); err != nil {
+		return nil, fmt.Errorf("%s: failed to register db stats collector: %w", op, err)
+	}
+
+	checker.AddReadinessCheck("postgres", storage.Ping)
+	checker.AddReadinessCheck("migrations", func(ctx context.Context) error {
+		return migrator.CheckState(ctx, storage.GetDB())
+	})
+	if redisStorage != nil {
+		checker.AddReadinessCheck("redis", redisStorage.Ping)
+	}
+
+	codec, err := NewCodec(cfg, storage)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	// Инициализация репозитория
-	custRepo := customerRepo.New(storage.GetDB())
+	custRepo := customerRepo.New(storage, cfg.Postgres.QueryTimeout, codec)
 
 	// Инициализация сервиса
-	custService := customerService.New(log, custRepo)
+	attributesSvc := attributesService.New(log, attributesRepo.New(storage, cfg.Postgres.QueryTimeout))
+	gendersSvc := gendersService.New(log, gendersRepo.New(storage, cfg.Postgres.QueryTimeout))
+	custService := customerService.New(log, custRepo, attributesSvc, gendersSvc)
+	importService := importsService.New(log, importsRepo.New(storage, cfg.Postgres.QueryTimeout, codec), custRepo, gendersSvc, cfg.Imports)
+	dsarSvc := dsarService.New(log, dsarRepo.New(storage, cfg.Postgres.QueryTimeout, codec), cfg.DSAR, cfg.SecretKey)
+	consentSvc := consentService.New(log, consentRepo.New(storage, cfg.Postgres.QueryTimeout), cfg.Consents)
+	tagsSvc := tagsService.New(log, tagsRepo.New(storage, cfg.Postgres.QueryTimeout))
+	segmentSvc := segmentService.New(log, segmentRepo.New(storage, cfg.Postgres.QueryTimeout, codec))
+	preferencesSvc, err := preferencesService.New(log, preferencesRepo.New(storage, cfg.Postgres.QueryTimeout), cfg.Preferences)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	publisher, err := outboxService.NewPublisher(cfg.Events, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	relay := outboxService.NewRelay(log, outboxRepo.New(storage, cfg.Postgres.QueryTimeout), publisher, cfg.Events)
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store
+		switch cfg.RateLimit.Store {
+		case "redis":
+			if redisStorage == nil {
+				return nil, fmt.Errorf("%s: rate_limit store redis requires redis.enabled", op)
+			}
+			store = ratelimit.NewRedisStore(redisStorage.GetClient())
+		case "memory", "":
+			store = ratelimit.NewMemoryStore()
+		default:
+			return nil, fmt.Errorf("%s: unknown rate_limit store %q", op, cfg.RateLimit.Store)
+		}
+		limiter = ratelimit.New(store, cfg.RateLimit)
+	}
+
+	var (
+		idempotencyStore mw.IdempotencyStore
+		workers          []func(ctx context.Context)
+	)
+	if cfg.Idempotency.Enabled {
+		idemRepo := idempotencyRepo.New(storage, cfg.Postgres.QueryTimeout)
+		idempotencyStore = idemRepo
+		workers = append(workers, func(ctx context.Context) {
+			purgeIdempotencyKeys(ctx, idemRepo, cfg.Idempotency.PurgeInterval, log)
+		})
+	}
+
+	workers = append(workers, func(ctx context.Context) {
+		storage.RunReplicaHealthCheck(ctx, cfg.Postgres.ReplicaCheckInterval)
+	}, func(ctx context.Context) {
+		importService.Run(ctx)
+	}, func(ctx context.Context) {
+		dsarSvc.Run(ctx)
+	}, func(ctx context.Context) {
+		relay.Run(ctx)
+	})
 
-	restApp := rest.New(
-		log,
+	if cfg.Scheduler.Enabled {
+		notifier, err := greetingsService.NewNotifier(cfg.Scheduler, log)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		greetingsSvc := greetingsService.New(log, custRepo, greetingsRepo.New(storage, cfg.Postgres.QueryTimeout), preferencesSvc, notifier, cfg.Scheduler)
+
+		scheduler := schedulerService.New(log, storage.NewAdvisoryLock(cfg.Scheduler.LockKey), cfg.Scheduler)
+		scheduler.Add(schedulerService.Job{
+			Name:     "birthday_greetings",
+			Interval: cfg.Scheduler.BirthdayInterval,
+			Run:      greetingsSvc.SendBirthdayGreetings,
+		})
+		workers = append(workers, scheduler.Run)
+	}
+
+	restApp.Mount(
 		custService,
-		cfg.Server.Port,
+		importService,
+		dsarSvc,
+		consentSvc,
+		preferencesSvc,
+		attributesSvc,
+		tagsSvc,
+		segmentSvc,
+		gendersSvc,
+		limiter,
+		idempotencyStore,
 	)
 
-	return &App{
-		log:     log,
-		storage: storage,
-		restApp: restApp,
+	var adminApp *admin.App
+	if cfg.Metrics.Enabled {
+		adminApp = admin.New(
+			log,
+			cfg.Metrics.Port,
+			cfg.Metrics.Path,
+		)
 	}
+
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+
+	return &App{
+		log:      log,
+		storage:  storage,
+		redis:    redisStorage,
+		restApp:  restApp,
+		adminApp: adminApp,
+		checker:  checker,
+		serveErr: serveErr,
+
+		drainDelay:      cfg.Health.DrainDelay,
+		shutdownTracing: shutdownTracing,
+
+		workers:  workers,
+		bgCtx:    bgCtx,
+		bgCancel: bgCancel,
+	}, nil
 }
 
 func (a *App) MustRun() {
 	const op = "app.MustRun"
 	a.log.With(slog.String("op", op)).Info("starting application")
 
-	if err := a.restApp.Run(); err != nil {
+	if a.adminApp != nil {
+		go func() {
+			if err := a.adminApp.Run(); err != nil {
+				panic(err)
+			}
+		}()
+	}
+
+	for _, w := range a.workers {
+		a.workersWg.Add(1)
+		go func() {
+			defer a.workersWg.Done()
+			w(a.bgCtx)
+		}()
+	}
+
+	// migrations ran in New, the API is mounted and workers are up
+	a.checker.MarkStarted()
+
+	if err := <-a.serveErr; err != nil {
 		panic(err)
 	}
 }
 
 func (a *App) GracefulShutdown() {
 	const op = "app.GracefulShutdown"
-	a.log.With(slog.String("op", op)).Info("shutting down application")
+	log := a.log.With(slog.String("op", op))
+	log.Info("shutting down application")
+
+	// Fail readiness first and give load balancers time to drain traffic.
+	a.checker.MarkShuttingDown()
+	if a.drainDelay > 0 {
+		log.Info("draining traffic", slog.Duration("delay", a.drainDelay))
+		time.Sleep(a.drainDelay)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := a.restApp.Stop(ctx); err != nil {
-		a.log.Error("failed to stop HTTP server", err)
+		a.log.Error("failed to stop HTTP server", "error", err)
+	}
+
+	if a.adminApp != nil {
+		if err := a.adminApp.Stop(ctx); err != nil {
+			a.log.Error("failed to stop admin server", "error", err)
+		}
+	}
+
+	// workers use storage until they return
+	a.bgCancel()
+	workersDone := make(chan struct{})
+	go func() {
+		a.workersWg.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		a.log.Error("background workers did not stop in time", "error", ctx.Err())
+	}
+
+	if err := a.shutdownTracing(ctx); err != nil {
+		a.log.Error("failed to flush traces", "error", err)
+	}
+
+	if a.redis != nil {
+		if err := a.redis.Close(); err != nil {
+			a.log.Error("failed to close redis connection", "error", err)
+		}
 	}
 
 	if a.storage != nil {
@@ -71,3 +395,29 @@ func (a *App) GracefulShutdown() {
 		a.log.Info("database connection closed")
 	}
 }
+
+// purgeIdempotencyKeys periodically deletes expired idempotency keys.
+func purgeIdempotencyKeys(ctx context.Context, repo *idempotencyRepo.Repository, interval time.Duration, log *slog.Logger) {
+	const op = "app.purgeIdempotencyKeys"
+
+	log = log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := repo.PurgeExpired(ctx)
+			if err != nil {
+				log.Error("failed to purge idempotency keys", slog.String("error", err.Error()))
+				continue
+			}
+			if n > 0 {
+				log.Info("expired idempotency keys purged", slog.Int64("count", n))
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"fmt"
+
+	"user-service/internal/config"
+	"user-service/internal/lib/envelope"
+	"user-service/internal/storage/pii"
+	"user-service/internal/storage/psql"
+	dataKeysRepo "user-service/internal/storage/repository/datakeys"
+)
+
+// NewCodec returns codec for customer PII columns. With encryption
+// disabled rows are written in plaintext, encrypted rows still need
+// the keyring to be read.
+func NewCodec(cfg *config.Config, storage *psql.Storage) (*pii.Codec, error) {
+	const op = "app.NewCodec"
+
+	if !cfg.Encryption.Enabled {
+		return pii.NewCodec(nil, nil, false)
+	}
+
+	keyring, err := envelope.NewKeyring(cfg.Encryption)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	cipher := envelope.New(keyring, dataKeysRepo.New(storage, cfg.Postgres.QueryTimeout), cfg.Encryption.KeyRefreshInterval)
+
+	codec, err := pii.NewCodec(cipher, cfg.Encryption.Columns, cfg.Encryption.BirthdayMonthDay)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return codec, nil
+}
@@ -2,51 +2,152 @@ package rest
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"sync/atomic"
 
+	"user-service/internal/config"
+	mw "user-service/internal/http/middleware"
 	v1 "user-service/internal/http/v1"
+	"user-service/internal/lib/health"
+	"user-service/internal/lib/ratelimit"
+	"user-service/internal/lib/tlsutil"
+	attributesService "user-service/internal/service/attributes"
+	consentService "user-service/internal/service/consent"
 	customerService "user-service/internal/service/customer"
+	dsarService "user-service/internal/service/dsar"
+	gendersService "user-service/internal/service/genders"
+	importsService "user-service/internal/service/imports"
+	preferencesService "user-service/internal/service/preferences"
+	segmentService "user-service/internal/service/segment"
+	tagsService "user-service/internal/service/tags"
 
 	"github.com/go-chi/chi/v5"
 )
 
 type App struct {
-	log             *slog.Logger
-	customerService *customerService.Service
-	httpServer      *http.Server
+	log         *slog.Logger
+	httpServer  *http.Server
+	tlsReloader *tlsutil.Reloader
+	cfg         *config.Config
+	checker     *health.Checker
+	listener    net.Listener
+
+	// handler serves probes only until Mount swaps in the API router
+	handler atomic.Pointer[http.Handler]
+
+	// reloadCtx is created with the app so Stop never races Run
+	reloadCtx  context.Context
+	stopReload context.CancelFunc
 }
 
+// New creates REST server which answers probes only, the API is served
+// after Mount so probes report startup progress while dependencies are
+// connected and migrated.
 func New(
 	log *slog.Logger,
-	customerService *customerService.Service,
-	port string,
+	checker *health.Checker,
+	cfg *config.Config,
+	tlsReloader *tlsutil.Reloader,
 ) *App {
+	a := &App{
+		log:         log,
+		tlsReloader: tlsReloader,
+		cfg:         cfg,
+		checker:     checker,
+	}
+
+	startup := chi.NewRouter()
+	v1.SetupStartupRoutes(startup, checker)
+	a.setHandler(startup)
+
+	a.httpServer = &http.Server{
+		Addr: ":" + cfg.Server.Port,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			(*a.handler.Load()).ServeHTTP(w, r)
+		}),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(log.Handler(), slog.LevelWarn),
+	}
+	if tlsReloader != nil {
+		a.httpServer.TLSConfig = tlsReloader.ServerConfig()
+	}
+
+	a.reloadCtx, a.stopReload = context.WithCancel(context.Background())
+
+	return a
+}
+
+// Mount starts serving API routes.
+func (a *App) Mount(
+	customerService *customerService.Service,
+	importService *importsService.Service,
+	dsarService *dsarService.Service,
+	consentService *consentService.Service,
+	preferencesService *preferencesService.Service,
+	attributesService *attributesService.Service,
+	tagsService *tagsService.Service,
+	segmentService *segmentService.Service,
+	gendersService *gendersService.Service,
+	limiter *ratelimit.Limiter,
+	idempotencyStore mw.IdempotencyStore,
+) {
 	r := chi.NewRouter()
 
 	// Регистрация маршрутов
-	v1.SetupRoutes(r, customerService, log)
+	v1.SetupRoutes(r, customerService, importService, dsarService, consentService, preferencesService, attributesService, tagsService, segmentService, gendersService, a.checker, a.cfg, limiter, idempotencyStore, a.log)
 
-	httpServer := &http.Server{
-		Addr:    ":" + port,
-		Handler: r,
-	}
+	a.setHandler(r)
+}
+
+func (a *App) setHandler(h http.Handler) {
+	a.handler.Store(&h)
+}
+
+// Listen binds the server port, a busy port fails startup right away.
+func (a *App) Listen() error {
+	const op = "app.rest.Listen"
 
-	return &App{
-		log:             log,
-		customerService: customerService,
-		httpServer:      httpServer,
+	ln, err := net.Listen("tcp", a.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
+	a.listener = ln
+	return nil
 }
 
+// Run serves on the port bound by Listen until Stop.
 func (a *App) Run() error {
 	const op = "app.rest.Run"
-	a.log.With(slog.String("op", op)).Info("starting REST server", "port", a.httpServer.Addr)
-	return a.httpServer.ListenAndServe()
+
+	if a.tlsReloader == nil {
+		a.log.With(slog.String("op", op)).Info("starting REST server", "port", a.httpServer.Addr)
+		if err := a.httpServer.Serve(a.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+
+	go a.tlsReloader.Run(a.reloadCtx)
+
+	a.log.With(slog.String("op", op)).Info("starting REST server with TLS", "port", a.httpServer.Addr)
+	// certificates come from TLSConfig
+	if err := a.httpServer.ServeTLS(a.listener, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 func (a *App) Stop(ctx context.Context) error {
 	const op = "app.rest.Stop"
 	a.log.With(slog.String("op", op)).Info("stopping REST server")
+	a.stopReload()
 	return a.httpServer.Shutdown(ctx)
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -10,17 +11,59 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+// minSecretKeyLength SecretKey signs download links and keys log hashes,
+// a short key is as good as none.
+const minSecretKeyLength = 32
+
 type Config struct {
-	Server    ServerConfig   `yaml:"server"`
-	Postgres  PostgresConfig `yaml:"postgres"`
-	SecretKey string         `yaml:"secret_key"`
-	//RedisConfig RedisConfig    `yaml:"redis"`
-	TokenTTL time.Duration `yaml:"token_ttl"`
+	Server    ServerConfig    `yaml:"server"`
+	Postgres  PostgresConfig  `yaml:"postgres"`
+	SecretKey string          `yaml:"secret_key" env:"SECRET_KEY"`
+	Redis     RedisConfig     `yaml:"redis"`
+	TokenTTL  time.Duration   `yaml:"token_ttl"`
+	Health    HealthConfig    `yaml:"health"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+
+	// APIKeys API key by client name, callers sending a listed X-API-Key
+	// are identified by the client name
+	APIKeys map[string]string `yaml:"api_keys"`
+
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Imports     ImportsConfig     `yaml:"imports"`
+	DSAR        DSARConfig        `yaml:"dsar"`
+	Events      EventsConfig      `yaml:"events"`
+	Encryption  EncryptionConfig  `yaml:"encryption"`
+	Log         LogConfig         `yaml:"log"`
+	Consents    ConsentsConfig    `yaml:"consents"`
+	Preferences PreferencesConfig `yaml:"preferences"`
+	Scheduler   SchedulerConfig   `yaml:"scheduler"`
 }
 
 type ServerConfig struct {
 	Port    string        `yaml:"port"`
-	Timeout time.Duration `yaml:"timeout"`
+	Timeout time.Duration `yaml:"timeout" env-default:"30s"` // per-request handler deadline
+
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env-default:"5s"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env-default:"15s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env-default:"2m30s"` // must exceed Timeout
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env-default:"2m"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env-default:"1048576"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes" env-default:"1048576"`
+
+	TLS TLSConfig `yaml:"tls"`
+}
+
+// TLSConfig native TLS termination. If ClientCAFile is set, API clients
+// must present certificate signed by it (mTLS) and its subject becomes
+// caller identity. Health probes are served without client certificate.
+type TLSConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	CertFile       string        `yaml:"cert_file"`
+	KeyFile        string        `yaml:"key_file"`
+	ClientCAFile   string        `yaml:"client_ca_file"`
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"30s"`
 }
 
 type PostgresConfig struct {
@@ -31,28 +74,240 @@ type PostgresConfig struct {
 	DbName   string `yaml:"dbname"`
 	Path     string `yaml:"path"` // TODO: may be delete
 	SslMode  string `yaml:"ssl_mode"`
+
+	MaxOpenConns     int           `yaml:"max_open_conns" env-default:"25"`
+	MaxIdleConns     int           `yaml:"max_idle_conns" env-default:"10"`
+	ConnMaxLifetime  time.Duration `yaml:"conn_max_lifetime" env-default:"30m"`
+	ConnMaxIdleTime  time.Duration `yaml:"conn_max_idle_time" env-default:"5m"`
+	StatementTimeout time.Duration `yaml:"statement_timeout"`              // server side, 0 - disabled
+	QueryTimeout     time.Duration `yaml:"query_timeout" env-default:"5s"` // client side, per repository call
+
+	ConnectRetry RetryConfig `yaml:"connect_retry"`
+
+	// Read replicas share user, password, dbname and pool settings with primary.
+	Replicas             []ReplicaConfig `yaml:"replicas"`
+	ReplicaCheckInterval time.Duration   `yaml:"replica_check_interval" env-default:"5s"`
+}
+
+type ReplicaConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+// RetryConfig exponential backoff policy for connecting to dependencies.
+type RetryConfig struct {
+	MaxElapsedTime  time.Duration `yaml:"max_elapsed_time" env-default:"1m"`
+	InitialInterval time.Duration `yaml:"initial_interval" env-default:"500ms"`
+	MaxInterval     time.Duration `yaml:"max_interval" env-default:"10s"`
+	Multiplier      float64       `yaml:"multiplier" env-default:"2"`
+	Jitter          float64       `yaml:"jitter" env-default:"0.2"`
+}
+
+// MetricsConfig admin server which exposes prometheus metrics.
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Port    string `yaml:"port" env-default:"9090"`
+	Path    string `yaml:"path" env-default:"/metrics"`
+}
+
+// TracingConfig OpenTelemetry tracing. Exporter is "otlp" or "stdout".
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	ServiceName string  `yaml:"service_name" env-default:"user-service"`
+	Exporter    string  `yaml:"exporter" env-default:"stdout"`
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4318"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+}
+
+// RateLimitConfig token bucket limits per client. Store is "memory" or "redis".
+// Routes overrides are keyed by "METHOD /pattern", e.g. "POST /api/v1/customers".
+type RateLimitConfig struct {
+	Enabled bool                   `yaml:"enabled"`
+	Store   string                 `yaml:"store" env-default:"memory"`
+	Default LimitConfig            `yaml:"default"`
+	Routes  map[string]LimitConfig `yaml:"routes"`
+}
+
+type LimitConfig struct {
+	Rate  float64 `yaml:"rate"` // tokens per second
+	Burst int     `yaml:"burst"`
+}
+
+// IdempotencyConfig Idempotency-Key handling for POST requests. Keys live for TTL,
+// a processing key older than LockTimeout is considered abandoned.
+type IdempotencyConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	TTL           time.Duration `yaml:"ttl" env-default:"24h"`
+	LockTimeout   time.Duration `yaml:"lock_timeout" env-default:"5m"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// ImportsConfig async customer import jobs. Uploads are spooled to SpoolDir
+// within UploadTimeout and loaded in chunks of ChunkSize rows by Workers
+// goroutines.
+type ImportsConfig struct {
+	MaxUploadBytes int64         `yaml:"max_upload_bytes" env-default:"104857600"`
+	UploadTimeout  time.Duration `yaml:"upload_timeout" env-default:"30m"` // replaces server read and write timeouts
+	SpoolDir       string        `yaml:"spool_dir"`
+	Workers        int           `yaml:"workers" env-default:"2"`
+	QueueSize      int           `yaml:"queue_size" env-default:"100"`
+	ChunkSize      int           `yaml:"chunk_size" env-default:"1000"`
+}
+
+// DSARConfig data subject access request archives. Archives are written to Dir,
+// download links are valid for LinkTTL and files are removed after Retention.
+type DSARConfig struct {
+	Dir           string        `yaml:"dir" env-default:"./data/dsar"`
+	LinkTTL       time.Duration `yaml:"link_ttl" env-default:"1h"`
+	Retention     time.Duration `yaml:"retention" env-default:"168h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+	Workers       int           `yaml:"workers" env-default:"1"`
+	QueueSize     int           `yaml:"queue_size" env-default:"100"`
+}
+
+// EventsConfig outbox relay. Pending events are polled every PollInterval
+// in batches of BatchSize, published ones are kept for Retention. The
+// webhook publisher posts each event to WebhookURL.
+type EventsConfig struct {
+	Publisher      string        `yaml:"publisher" env-default:"log"`
+	WebhookURL     string        `yaml:"webhook_url" env:"EVENTS_WEBHOOK_URL"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" env-default:"10s"`
+	PollInterval   time.Duration `yaml:"poll_interval" env-default:"1s"`
+	BatchSize      int           `yaml:"batch_size" env-default:"100"`
+	Retention      time.Duration `yaml:"retention" env-default:"168h"`
+	PurgeInterval  time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// EncryptionConfig envelope encryption of customer PII columns. Master keys
+// come from KeyFile (keyring: file) or the KMS stand-in (keyring: kms),
+// writers pick up a rotated data key within KeyRefreshInterval.
+// BirthdayMonthDay keeps month and day of an encrypted birthday in clear
+// so birthday queries use an index, without it every customer with an
+// encrypted birthday is decrypted and checked by each birthday query.
+// Existing rows follow a change on the next key rotation.
+type EncryptionConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	Columns            []string      `yaml:"columns" env-default:"first_name,last_name,birthday"`
+	Keyring            string        `yaml:"keyring" env-default:"file"`
+	KeyFile            string        `yaml:"key_file" env:"PII_KEY_FILE"`
+	KMSSeed            string        `yaml:"kms_seed" env:"PII_KMS_SEED"`
+	KMSKeyID           string        `yaml:"kms_key_id" env-default:"v1"`
+	KeyRefreshInterval time.Duration `yaml:"key_refresh_interval" env-default:"1m"`
+	RotationBatchSize  int           `yaml:"rotation_batch_size" env-default:"500"`
+	BirthdayMonthDay   bool          `yaml:"birthday_month_day"`
+}
+
+// ConsentsConfig purposes a customer can give consent for.
+type ConsentsConfig struct {
+	Purposes []string `yaml:"purposes" env-default:"email_marketing,sms_marketing,profiling"`
+}
+
+// PreferencesConfig defaults for customers who have not set their
+// preferences and the notification channels they can choose from.
+// Quiet hours are off by default, DefaultQuietHours is "HH:MM-HH:MM".
+type PreferencesConfig struct {
+	DefaultLocale     string   `yaml:"default_locale" env-default:"en-US"`
+	DefaultCurrency   string   `yaml:"default_currency" env-default:"USD"`
+	DefaultChannels   []string `yaml:"default_channels" env-default:"email"`
+	DefaultQuietHours string   `yaml:"default_quiet_hours"`
+	Channels          []string `yaml:"channels" env-default:"email,sms,push"`
+}
+
+// SchedulerConfig periodic jobs. Replicas compete for the Postgres
+// advisory lock LockKey every ElectionInterval, only the holder runs
+// jobs. Birthday greetings are checked every BirthdayInterval and sent
+// from BirthdayHour local time through Notifier, candidates are read in
+// batches of BatchSize. BirthdayHour left out means 9, 0 is midnight.
+type SchedulerConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	LockKey          int64         `yaml:"lock_key" env-default:"730001"`
+	ElectionInterval time.Duration `yaml:"election_interval" env-default:"15s"`
+	Notifier         string        `yaml:"notifier" env-default:"log"`
+	BirthdayInterval time.Duration `yaml:"birthday_interval" env-default:"5m"`
+	BirthdayHour     *int          `yaml:"birthday_hour"`
+	BatchSize        int           `yaml:"batch_size" env-default:"500"`
+}
+
+const defaultBirthdayHour = 9
+
+// GreetingHour returns local hour greetings are sent from.
+func (c SchedulerConfig) GreetingHour() int {
+	if c.BirthdayHour == nil {
+		return defaultBirthdayHour
+	}
+	return *c.BirthdayHour
+}
+
+// LogConfig redaction of PII before records are written, set per
+// environment in its config file. Values of Mask keys are replaced,
+// values of Hash keys are replaced with a keyed hash so records can
+// still be correlated. Scrub keys and messages have emails, phone
+// numbers, dates and key=value pairs of masked keys removed.
+// Lists left out get the defaults, an explicit [] empties one.
+// DebugUnredacted turns redaction off, it only takes effect together
+// with the -log-unredacted command line flag.
+type LogConfig struct {
+	Mask            []string `yaml:"mask" env-default:"first_name,last_name,name,birthday,email,phone,address"`
+	Hash            []string `yaml:"hash" env-default:"customer_id,user_id"`
+	Scrub           []string `yaml:"scrub" env-default:"error"`
+	DebugUnredacted bool     `yaml:"debug_unredacted"`
+	UnredactedFlag  bool     `yaml:"-"`
 }
 
-//type RedisConfig struct {
-//	Addr     string `yaml:"addr"`
-//	Password string `yaml:"password"`
-//	DB       int    `yaml:"db"`
-//}
+type RedisConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Addr     string `yaml:"addr" env-default:"localhost:6379"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+
+	ConnectRetry RetryConfig `yaml:"connect_retry"`
+}
 
+// HealthConfig probes settings. DrainDelay is how long readiness stays
+// failing before the server stops accepting connections.
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env-default:"2s"`
+	DrainDelay   time.Duration `yaml:"drain_delay" env-default:"5s"`
+}
+
+// MustLoad is Load for commands that have nothing to clean up, it panics
+// on error.
 func MustLoad() *Config {
+	cfg, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
 
-	path := fetchConfigPath()
+// Load reads config from the file given by -config or CONFIG_PATH,
+// environment variables take precedence over the file.
+func Load() (*Config, error) {
+	path, unredacted := fetchConfigPath()
 	fmt.Println(path)
 	if path == "" {
-		panic("config path is empty")
+		return nil, errors.New("config path is empty")
 	}
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		panic("config file does not exist: " + path)
+		return nil, fmt.Errorf("config file does not exist: %s", path)
 	}
 	var cfg Config
 
 	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
-		panic("failed to read config: " + err.Error())
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg.Log.UnredactedFlag = unredacted
+
+	if err := cfg.Log.validate(); err != nil {
+		return nil, err
+	}
+	if h := cfg.Scheduler.GreetingHour(); h < 0 || h > 23 {
+		return nil, fmt.Errorf("scheduler.birthday_hour must be between 0 and 23, got %d", h)
+	}
+
+	if len(cfg.SecretKey) < minSecretKeyLength {
+		return nil, fmt.Errorf("secret_key must be at least %d bytes, set it in config or SECRET_KEY", minSecretKeyLength)
 	}
 
 	// Переопределение из переменных окружения (приоритет над файлом)
@@ -82,22 +337,49 @@ func MustLoad() *Config {
 		cfg.Postgres.SslMode = sslmode
 		fmt.Printf("Override Postgres SSLMode from ENV: %s\n", sslmode)
 	}
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		cfg.Redis.Addr = addr
+		fmt.Printf("Override Redis Addr from ENV: %s\n", addr)
+	}
+	if password := os.Getenv("REDIS_PASSWORD"); password != "" {
+		cfg.Redis.Password = password
+		fmt.Println("Override Redis Password from ENV: ***")
+	}
 
 	fmt.Printf("Final Postgres Config: %s:%d/%s (user: %s, sslmode: %s)\n",
 		cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.DbName, cfg.Postgres.User, cfg.Postgres.SslMode)
 
-	return &cfg
+	return &cfg, nil
+}
+
+// validate rejects a policy that redacts nothing unless redaction is
+// turned off on purpose.
+func (c LogConfig) validate() error {
+	if len(c.Mask) > 0 || len(c.Hash) > 0 || len(c.Scrub) > 0 {
+		return nil
+	}
+	if c.DebugUnredacted && c.UnredactedFlag {
+		return nil
+	}
+	return errors.New("log.mask, log.hash and log.scrub are all empty, " +
+		"set log.debug_unredacted and -log-unredacted to run without redaction")
 }
 
 // fetchConfigPath fetches config path from command line flag or env.
 // Priority: env > flag
-func fetchConfigPath() string {
-	var res string
+// Also returns the -log-unredacted flag, it is never read from env or
+// config so unredacted logs cannot be turned on by a config change alone.
+func fetchConfigPath() (string, bool) {
+	var (
+		res        string
+		unredacted bool
+	)
 	flag.StringVar(&res, "config", "", "path to config file")
+	flag.BoolVar(&unredacted, "log-unredacted", false, "allow log.debug_unredacted to disable PII redaction")
 	flag.Parse()
 
 	if res == "" {
 		res = os.Getenv("CONFIG_PATH")
 	}
-	return res
+	return res, unredacted
 }
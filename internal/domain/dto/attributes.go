@@ -0,0 +1,76 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+const (
+	// MaxAttributesBytes limits encoded attributes of a single write.
+	MaxAttributesBytes = 16 << 10
+	// MaxAttributeFilters limits attribute filters of a single list request.
+	MaxAttributeFilters = 10
+)
+
+var namespacePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// ValidateNamespace checks attribute namespace name: lowercase letters,
+// digits and underscores, starting with a letter.
+func ValidateNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid attribute namespace %q, must match %s", namespace, namespacePattern)
+	}
+	return nil
+}
+
+// AttributeFilter matches customers whose attribute at Path equals Value.
+// Path starts with the namespace. Value comes from the query as string,
+// the service converts it to the type the schema declares.
+type AttributeFilter struct {
+	Path  []string
+	Value any
+}
+
+// validateAttributes checks namespaces and size, values are checked
+// against their schemas by the service. Null removes namespace on update.
+func validateAttributes(attrs map[string]json.RawMessage, allowNull bool) error {
+	size := 0
+	for namespace, value := range attrs {
+		if err := ValidateNamespace(namespace); err != nil {
+			return err
+		}
+		if !allowNull && IsNull(value) {
+			return fmt.Errorf("attributes.%s cannot be null", namespace)
+		}
+		size += len(namespace) + len(value)
+	}
+	if size > MaxAttributesBytes {
+		return fmt.Errorf("attributes too large, max %d bytes", MaxAttributesBytes)
+	}
+	return nil
+}
+
+// IsNull reports whether raw is JSON null.
+func IsNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+func validateAttributeFilters(filters []AttributeFilter) error {
+	if len(filters) > MaxAttributeFilters {
+		return fmt.Errorf("too many attribute filters, max %d", MaxAttributeFilters)
+	}
+	for _, f := range filters {
+		if len(f.Path) < 2 {
+			return errors.New("attribute filter must be attr.<namespace>.<path>")
+		}
+		for _, name := range f.Path {
+			if name == "" {
+				return errors.New("attribute filter path has an empty segment")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"user-service/internal/domain/models"
+)
+
+// MaxBirthdayWindow longest window of a birthdays query.
+const MaxBirthdayWindow = 366 * 24 * time.Hour
+
+// BirthdaysFilter window of a birthdays query, From is inclusive and To
+// exclusive. A customer matches if their local birthday overlaps it.
+type BirthdaysFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+func (f *BirthdaysFilter) Validate() error {
+	if !f.From.Before(f.To) {
+		return errors.New("from must be before to")
+	}
+	if f.To.Sub(f.From) > MaxBirthdayWindow {
+		return errors.New("birthday window too long, max 366 days")
+	}
+	return nil
+}
+
+// CustomerBirthday customer whose birthday falls into the window, On is
+// start of the birthday in the customer's timezone.
+type CustomerBirthday struct {
+	Customer models.Customer
+	On       time.Time
+}
+
+// MaxAge upper bound of age checks, birthdays are at most 150 years ago.
+const MaxAge = 150
+
+func ValidateMinAge(minAge int) error {
+	if minAge < 0 || minAge > MaxAge {
+		return errors.New("min must be between 0 and 150")
+	}
+	return nil
+}
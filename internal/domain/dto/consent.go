@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type UpdateConsentsRequest struct {
+	Consents []ConsentInput `json:"consents"`
+}
+
+type ConsentInput struct {
+	Purpose           string `json:"purpose"`
+	Status            string `json:"status"`
+	Source            string `json:"source"`
+	LegalBasisVersion string `json:"legal_basis_version"`
+}
+
+// Validate checks fields, allowed purposes are checked by the service.
+func (r *UpdateConsentsRequest) Validate() error {
+	if len(r.Consents) == 0 {
+		return errors.New("consents are required")
+	}
+
+	seen := make(map[string]bool, len(r.Consents))
+	for i := range r.Consents {
+		c := &r.Consents[i]
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("consents[%d]: %w", i, err)
+		}
+		if seen[c.Purpose] {
+			return fmt.Errorf("consents[%d]: duplicate purpose %s", i, c.Purpose)
+		}
+		seen[c.Purpose] = true
+	}
+	return nil
+}
+
+func (c *ConsentInput) Validate() error {
+	c.Purpose = strings.ToLower(strings.TrimSpace(c.Purpose))
+	c.Status = strings.ToLower(strings.TrimSpace(c.Status))
+	c.Source = strings.TrimSpace(c.Source)
+	c.LegalBasisVersion = strings.TrimSpace(c.LegalBasisVersion)
+
+	if c.Purpose == "" {
+		return errors.New("purpose is required")
+	}
+	if c.Status != "granted" && c.Status != "withdrawn" {
+		return errors.New("status must be granted or withdrawn")
+	}
+	if c.Source == "" {
+		return errors.New("source is required")
+	}
+	if len(c.Source) > 50 {
+		return errors.New("source too long, max 50 characters")
+	}
+	if c.LegalBasisVersion == "" {
+		return errors.New("legal_basis_version is required")
+	}
+	if len(c.LegalBasisVersion) > 50 {
+		return errors.New("legal_basis_version too long, max 50 characters")
+	}
+	return nil
+}
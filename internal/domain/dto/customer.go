@@ -1,9 +1,13 @@
 package dto
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
+
+	"user-service/internal/lib/timezone"
 )
 
 type CreateCustomerRequest struct {
@@ -13,6 +17,8 @@ type CreateCustomerRequest struct {
 	Timezone  string `json:"timezone"`
 	Birthday  string `json:"birthday"`
 	UserID    string `json:"user_id"`
+	// Attributes custom fields by namespace, see ValidateNamespace.
+	Attributes map[string]json.RawMessage `json:"attributes,omitempty"`
 }
 
 func (c *CreateCustomerRequest) Validate() error {
@@ -31,13 +37,19 @@ func (c *CreateCustomerRequest) Validate() error {
 	if strings.TrimSpace(c.Gender) == "" {
 		return errors.New("gender is required")
 	}
-	gender := strings.ToLower(strings.TrimSpace(c.Gender))
-	if gender != "male" && gender != "female" {
-		return errors.New("gender must be male or female")
+	gender, err := NormalizeGender(c.Gender)
+	if err != nil {
+		return err
 	}
+	c.Gender = gender
 	if c.Timezone == "" {
 		c.Timezone = "UTC"
 	}
+	tz, err := ParseTimezone(c.Timezone)
+	if err != nil {
+		return err
+	}
+	c.Timezone = tz
 	if strings.TrimSpace(c.Birthday) == "" {
 		return errors.New("birthday is required")
 	}
@@ -57,7 +69,7 @@ func (c *CreateCustomerRequest) Validate() error {
 	if c.UserID == "" {
 		return errors.New("user_id is required")
 	}
-	return nil
+	return validateAttributes(c.Attributes, false)
 }
 
 func (c *CreateCustomerRequest) ParseBirthday() (time.Time, error) {
@@ -70,6 +82,8 @@ type UpdateCustomerRequest struct {
 	Gender    *string `json:"gender,omitempty"`
 	Timezone  *string `json:"timezone,omitempty"`
 	Birthday  *string `json:"birthday,omitempty"`
+	// Attributes replaces listed namespaces, null removes a namespace.
+	Attributes map[string]json.RawMessage `json:"attributes,omitempty"`
 }
 
 func (r *UpdateCustomerRequest) Validate() error {
@@ -94,10 +108,19 @@ func (r *UpdateCustomerRequest) Validate() error {
 		if strings.TrimSpace(*r.Gender) == "" {
 			return errors.New("gender cannot be empty")
 		}
-		gender := strings.ToLower(strings.TrimSpace(*r.Gender))
-		if gender != "male" && gender != "female" {
-			return errors.New("gender must be male or female")
+		gender, err := NormalizeGender(*r.Gender)
+		if err != nil {
+			return err
+		}
+		r.Gender = &gender
+	}
+
+	if r.Timezone != nil {
+		tz, err := ParseTimezone(*r.Timezone)
+		if err != nil {
+			return err
 		}
+		r.Timezone = &tz
 	}
 
 	if r.Birthday != nil {
@@ -114,7 +137,7 @@ func (r *UpdateCustomerRequest) Validate() error {
 		}
 	}
 
-	return nil
+	return validateAttributes(r.Attributes, true)
 }
 func (r *UpdateCustomerRequest) ParseBirthday() (*time.Time, error) {
 	if r.Birthday == nil {
@@ -128,3 +151,175 @@ func (r *UpdateCustomerRequest) ParseBirthday() (*time.Time, error) {
 
 	return &t, nil
 }
+
+// MaxBatchOperations limits operations in a single batch request.
+const MaxBatchOperations = 1000
+
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+
+	BatchStatusCreated = "created"
+	BatchStatusUpdated = "updated"
+	BatchStatusError   = "error"
+)
+
+type BatchCustomersRequest struct {
+	Atomic     bool                     `json:"atomic"`
+	Operations []BatchCustomerOperation `json:"operations"`
+}
+
+// BatchCustomerOperation create (Create set) or update (ID and Update set).
+type BatchCustomerOperation struct {
+	Op     string                 `json:"op"`
+	ID     string                 `json:"id,omitempty"`
+	Create *CreateCustomerRequest `json:"create,omitempty"`
+	Update *UpdateCustomerRequest `json:"update,omitempty"`
+}
+
+func (r *BatchCustomersRequest) Validate() error {
+	if len(r.Operations) == 0 {
+		return errors.New("operations are required")
+	}
+	if len(r.Operations) > MaxBatchOperations {
+		return fmt.Errorf("too many operations, max %d", MaxBatchOperations)
+	}
+	return nil
+}
+
+// Validate checks a single operation with the create/update validators.
+func (o *BatchCustomerOperation) Validate() error {
+	switch o.Op {
+	case BatchOpCreate:
+		if o.Create == nil {
+			return errors.New("create is required for create operation")
+		}
+		return o.Create.Validate()
+	case BatchOpUpdate:
+		if strings.TrimSpace(o.ID) == "" {
+			return errors.New("id is required for update operation")
+		}
+		if o.Update == nil {
+			return errors.New("update is required for update operation")
+		}
+		return o.Update.Validate()
+	default:
+		return errors.New("op must be create or update")
+	}
+}
+
+// Attributes returns attributes written by the operation.
+func (o *BatchCustomerOperation) Attributes() map[string]json.RawMessage {
+	switch {
+	case o.Op == BatchOpCreate && o.Create != nil:
+		return o.Create.Attributes
+	case o.Op == BatchOpUpdate && o.Update != nil:
+		return o.Update.Attributes
+	}
+	return nil
+}
+
+type BatchItemError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type BatchItemResult struct {
+	Index  int             `json:"index"`
+	Status string          `json:"status"`
+	ID     string          `json:"id,omitempty"`
+	Error  *BatchItemError `json:"error,omitempty"`
+}
+
+type BatchCustomersResponse struct {
+	Atomic    bool              `json:"atomic"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// Fail marks item as failed.
+func (r *BatchItemResult) Fail(code, message string) {
+	r.Status = BatchStatusError
+	r.Error = &BatchItemError{Code: code, Message: message}
+}
+
+// Succeed marks item as created or updated depending on op.
+func (r *BatchItemResult) Succeed(op, id string) {
+	r.Status = BatchStatusCreated
+	if op == BatchOpUpdate {
+		r.Status = BatchStatusUpdated
+	}
+	r.ID = id
+	r.Error = nil
+}
+
+// CustomerFilter list/export filters, zero value matches every customer
+// that is not erased.
+// Name and birthday filters are exact matches, names ignore case. Search
+// matches either first or last name. Attribute filters are exact matches
+// too.
+type CustomerFilter struct {
+	Gender        string
+	Timezone      string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	FirstName     string
+	LastName      string
+	Birthday      *time.Time
+	Search        string
+	Attributes    []AttributeFilter
+}
+
+func (f *CustomerFilter) Validate() error {
+	if f.Gender != "" {
+		gender, err := NormalizeGender(f.Gender)
+		if err != nil {
+			return err
+		}
+		f.Gender = gender
+	}
+	if f.Timezone != "" {
+		tz, err := ParseTimezone(f.Timezone)
+		if err != nil {
+			return err
+		}
+		f.Timezone = tz
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return errors.New("created_after must be before created_before")
+	}
+	if len(f.FirstName) > 100 || len(f.LastName) > 100 || len(f.Search) > 100 {
+		return errors.New("name filters too long, max 100 characters")
+	}
+	return validateAttributeFilters(f.Attributes)
+}
+
+// ParseTimezone validates IANA time zone name and returns its canonical
+// form, deprecated names like US/Eastern become America/New_York.
+func ParseTimezone(s string) (string, error) {
+	tz, err := timezone.Normalize(s)
+	if err != nil {
+		return "", errors.New("timezone must be an IANA time zone name (e.g., Europe/Berlin)")
+	}
+	return tz, nil
+}
+
+type EraseCustomerRequest struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+func (r *EraseCustomerRequest) Validate() error {
+	r.Actor = strings.TrimSpace(r.Actor)
+	if r.Actor == "" {
+		return errors.New("actor is required")
+	}
+	if len(r.Actor) > 255 {
+		return errors.New("actor too long, max 255 characters")
+	}
+	if len(r.Reason) > 1000 {
+		return errors.New("reason too long, max 1000 characters")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var genderPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+// NormalizeGender trims and lower cases gender code and checks its
+// format. Whether the code is allowed is checked with GenderSet.
+func NormalizeGender(s string) (string, error) {
+	code := strings.ToLower(strings.TrimSpace(s))
+	if !genderPattern.MatchString(code) {
+		return "", errors.New("gender must be a lower case code of letters, digits and underscores, max 20 characters")
+	}
+	return code, nil
+}
+
+// GenderSet active codes of the gender enumeration.
+type GenderSet map[string]bool
+
+// Check returns an error listing allowed values if code is not one of them.
+func (s GenderSet) Check(code string) error {
+	if s[code] {
+		return nil
+	}
+	codes := make([]string, 0, len(s))
+	for c := range s {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	return fmt.Errorf("gender must be one of %s", strings.Join(codes, ", "))
+}
+
+// GenderRequest adds a value to the gender enumeration or replaces it.
+// Omitted active means active.
+type GenderRequest struct {
+	Label    string `json:"label"`
+	Position int    `json:"position"`
+	Active   *bool  `json:"active"`
+}
+
+func (r *GenderRequest) Validate() error {
+	r.Label = strings.TrimSpace(r.Label)
+	if r.Label == "" {
+		return errors.New("label is required")
+	}
+	if len(r.Label) > 100 {
+		return errors.New("label too long, max 100 characters")
+	}
+	if r.Position < 0 {
+		return errors.New("position must not be negative")
+	}
+	if r.Active == nil {
+		active := true
+		r.Active = &active
+	}
+	return nil
+}
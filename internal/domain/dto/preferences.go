@@ -0,0 +1,84 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+)
+
+// UpdatePreferencesRequest replaces preferences. Omitted or null fields
+// return to defaults, an empty notification_channels list turns
+// notifications off.
+type UpdatePreferencesRequest struct {
+	Locale               *string          `json:"locale"`
+	Currency             *string          `json:"currency"`
+	NotificationChannels []string         `json:"notification_channels"`
+	QuietHours           *QuietHoursInput `json:"quiet_hours"`
+}
+
+type QuietHoursInput struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Validate checks and canonicalizes values: locale is a BCP 47 tag
+// (en-us becomes en-US), currency an ISO 4217 code. Allowed channels are
+// checked by the service.
+func (r *UpdatePreferencesRequest) Validate() error {
+	if r.Locale != nil {
+		locale, err := ParseLocale(*r.Locale)
+		if err != nil {
+			return err
+		}
+		r.Locale = &locale
+	}
+	if r.Currency != nil {
+		unit, err := currency.ParseISO(strings.TrimSpace(*r.Currency))
+		if err != nil {
+			return errors.New("currency must be an ISO 4217 code")
+		}
+		code := unit.String()
+		r.Currency = &code
+	}
+	if r.NotificationChannels != nil {
+		seen := make(map[string]bool, len(r.NotificationChannels))
+		channels := make([]string, 0, len(r.NotificationChannels))
+		for _, ch := range r.NotificationChannels {
+			ch = strings.ToLower(strings.TrimSpace(ch))
+			if ch == "" {
+				return errors.New("notification channel must not be empty")
+			}
+			if !seen[ch] {
+				seen[ch] = true
+				channels = append(channels, ch)
+			}
+		}
+		r.NotificationChannels = channels
+	}
+	if r.QuietHours != nil {
+		for name, v := range map[string]*string{"start": &r.QuietHours.Start, "end": &r.QuietHours.End} {
+			t, err := time.Parse("15:04", strings.TrimSpace(*v))
+			if err != nil {
+				return fmt.Errorf("quiet_hours.%s must be HH:MM", name)
+			}
+			*v = t.Format("15:04")
+		}
+		if r.QuietHours.Start == r.QuietHours.End {
+			return errors.New("quiet_hours start and end must differ")
+		}
+	}
+	return nil
+}
+
+// ParseLocale validates BCP 47 tag and returns its canonical form.
+func ParseLocale(s string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(s))
+	if err != nil || tag == language.Und {
+		return "", errors.New("locale must be a BCP 47 language tag, e.g. en-US")
+	}
+	return tag.String(), nil
+}
@@ -0,0 +1,238 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"user-service/internal/domain/models"
+)
+
+const (
+	// MaxSegmentRuleDepth limits nesting of all, any and not.
+	MaxSegmentRuleDepth = 5
+	// MaxSegmentConditions limits field conditions of a single rule.
+	MaxSegmentConditions = 50
+	// MaxTagsPerRequest limits tags added by a single request.
+	MaxTagsPerRequest = 50
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// segmentOps operators allowed for each field.
+var segmentOps = map[string][]string{
+	models.SegmentFieldGender:         {models.SegmentOpEq, models.SegmentOpIn},
+	models.SegmentFieldTimezone:       {models.SegmentOpEq, models.SegmentOpIn},
+	models.SegmentFieldAge:            {models.SegmentOpGte, models.SegmentOpLte, models.SegmentOpBetween},
+	models.SegmentFieldCreatedAt:      {models.SegmentOpGte, models.SegmentOpLt, models.SegmentOpBetween},
+	models.SegmentFieldFavoritesCount: {models.SegmentOpEq, models.SegmentOpGte, models.SegmentOpLte, models.SegmentOpBetween},
+	models.SegmentFieldAttributes:     {models.SegmentOpEq},
+	models.SegmentFieldTag:            {models.SegmentOpEq},
+}
+
+type SegmentRequest struct {
+	Name  string              `json:"name"`
+	Rules *models.SegmentRule `json:"rules"`
+}
+
+func (r *SegmentRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > 100 {
+		return errors.New("name too long, max 100 characters")
+	}
+	if r.Rules == nil {
+		return errors.New("rules are required")
+	}
+	return ValidateSegmentRule(r.Rules)
+}
+
+type PreviewSegmentRequest struct {
+	Rules *models.SegmentRule `json:"rules"`
+}
+
+func (r *PreviewSegmentRequest) Validate() error {
+	if r.Rules == nil {
+		return errors.New("rules are required")
+	}
+	return ValidateSegmentRule(r.Rules)
+}
+
+// ValidateSegmentRule checks rule structure and that every condition
+// uses an operator and value type its field supports. Tag values are
+// rewritten normalized, tags are stored lower cased.
+func ValidateSegmentRule(rule *models.SegmentRule) error {
+	conditions := 0
+	if err := validateRule(rule, "rules", 1, &conditions); err != nil {
+		return err
+	}
+	if conditions > MaxSegmentConditions {
+		return fmt.Errorf("too many conditions, max %d", MaxSegmentConditions)
+	}
+	return nil
+}
+
+func validateRule(rule *models.SegmentRule, at string, depth int, conditions *int) error {
+	if depth > MaxSegmentRuleDepth {
+		return fmt.Errorf("%s: rules nested too deep, max %d levels", at, MaxSegmentRuleDepth)
+	}
+
+	kinds := 0
+	for _, set := range []bool{rule.All != nil, rule.Any != nil, rule.Not != nil, rule.Field != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("%s: rule must have exactly one of all, any, not or field", at)
+	}
+
+	switch {
+	case rule.All != nil || rule.Any != nil:
+		name, list := "all", rule.All
+		if rule.Any != nil {
+			name, list = "any", rule.Any
+		}
+		if len(list) == 0 {
+			return fmt.Errorf("%s.%s: must not be empty", at, name)
+		}
+		for i := range list {
+			if err := validateRule(&list[i], fmt.Sprintf("%s.%s[%d]", at, name, i), depth+1, conditions); err != nil {
+				return err
+			}
+		}
+		return nil
+	case rule.Not != nil:
+		return validateRule(rule.Not, at+".not", depth+1, conditions)
+	}
+
+	*conditions++
+	if err := validateCondition(rule); err != nil {
+		return fmt.Errorf("%s: %w", at, err)
+	}
+	return nil
+}
+
+func validateCondition(rule *models.SegmentRule) error {
+	ops, ok := segmentOps[rule.Field]
+	if !ok {
+		return fmt.Errorf("unknown field %s", rule.Field)
+	}
+	if !slices.Contains(ops, rule.Op) {
+		return fmt.Errorf("field %s supports operators %s", rule.Field, strings.Join(ops, ", "))
+	}
+	if rule.Field == models.SegmentFieldAttributes {
+		if len(rule.Path) == 0 {
+			return errors.New("path is required for attributes")
+		}
+		if err := ValidateNamespace(rule.Path[0]); err != nil {
+			return err
+		}
+		if slices.Contains(rule.Path, "") {
+			return errors.New("path has an empty segment")
+		}
+		if len(rule.Value) == 0 || IsNull(rule.Value) {
+			return errors.New("value is required")
+		}
+		return nil
+	}
+	if rule.Path != nil {
+		return errors.New("path is only allowed for attributes")
+	}
+
+	switch rule.Field {
+	case models.SegmentFieldGender, models.SegmentFieldTimezone:
+		if rule.Op == models.SegmentOpIn {
+			var list []string
+			if err := json.Unmarshal(rule.Value, &list); err != nil || len(list) == 0 || len(list) > 100 {
+				return errors.New("value must be an array of 1 to 100 strings")
+			}
+			return nil
+		}
+		var s string
+		if err := json.Unmarshal(rule.Value, &s); err != nil || s == "" {
+			return errors.New("value must be a non-empty string")
+		}
+	case models.SegmentFieldAge, models.SegmentFieldFavoritesCount:
+		limit := 1 << 30
+		if rule.Field == models.SegmentFieldAge {
+			limit = 150
+		}
+		if rule.Op == models.SegmentOpBetween {
+			var r []int
+			if err := json.Unmarshal(rule.Value, &r); err != nil || len(r) != 2 || r[0] < 0 || r[0] > r[1] || r[1] > limit {
+				return fmt.Errorf("value must be [min, max] with 0 <= min <= max <= %d", limit)
+			}
+			return nil
+		}
+		var n int
+		if err := json.Unmarshal(rule.Value, &n); err != nil || n < 0 || n > limit {
+			return fmt.Errorf("value must be an integer between 0 and %d", limit)
+		}
+	case models.SegmentFieldCreatedAt:
+		if rule.Op == models.SegmentOpBetween {
+			var r []time.Time
+			if err := json.Unmarshal(rule.Value, &r); err != nil || len(r) != 2 || !r[0].Before(r[1]) {
+				return errors.New("value must be [from, to] RFC 3339 timestamps with from before to")
+			}
+			return nil
+		}
+		var t time.Time
+		if err := json.Unmarshal(rule.Value, &t); err != nil {
+			return errors.New("value must be an RFC 3339 timestamp")
+		}
+	case models.SegmentFieldTag:
+		var s string
+		if err := json.Unmarshal(rule.Value, &s); err != nil {
+			return errors.New("value must be a string")
+		}
+		tag, err := NormalizeTag(s)
+		if err != nil {
+			return err
+		}
+		rule.Value, _ = json.Marshal(tag)
+	}
+	return nil
+}
+
+type TagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// Validate normalizes tags to lowercase and drops duplicates.
+func (r *TagsRequest) Validate() error {
+	if len(r.Tags) == 0 {
+		return errors.New("tags are required")
+	}
+	if len(r.Tags) > MaxTagsPerRequest {
+		return fmt.Errorf("too many tags, max %d", MaxTagsPerRequest)
+	}
+	tags := make([]string, 0, len(r.Tags))
+	for _, t := range r.Tags {
+		tag, err := NormalizeTag(t)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	r.Tags = tags
+	return nil
+}
+
+// NormalizeTag lowercases tag and checks it: letters, digits, dashes
+// and underscores, up to 64 characters.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(tag) {
+		return "", fmt.Errorf("invalid tag %q, must match %s", tag, tagPattern)
+	}
+	return tag, nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Attributes custom customer fields by namespace. Value of each namespace
+// is validated against the schema registered for it.
+type Attributes map[string]json.RawMessage
+
+// Value encodes attributes as JSON text, nil is stored as empty object.
+// Text rather than bytes so COPY does not send it as bytea.
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(map[string]json.RawMessage(a))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (a *Attributes) Scan(src any) error {
+	var b []byte
+	switch src := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		b = src
+	case string:
+		b = []byte(src)
+	default:
+		return fmt.Errorf("models.Attributes: cannot scan %T", src)
+	}
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	if len(m) == 0 {
+		m = nil
+	}
+	*a = m
+	return nil
+}
+
+// AttributeSchema JSON Schema registered for attribute namespace.
+type AttributeSchema struct {
+	Namespace string          `db:"namespace" json:"namespace"`
+	Schema    json.RawMessage `db:"schema" json:"schema"`
+	Version   int             `db:"version" json:"version"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
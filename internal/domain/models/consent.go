@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ConsentGranted   = "granted"
+	ConsentWithdrawn = "withdrawn"
+)
+
+// Consent record of a consent decision. Records are never changed, the
+// latest one per purpose is the current state.
+type Consent struct {
+	ID                int64     `db:"id" json:"id"`
+	CustomerID        uuid.UUID `db:"customer_id" json:"customer_id"`
+	Purpose           string    `db:"purpose" json:"purpose"`
+	Status            string    `db:"status" json:"status"`
+	Source            string    `db:"source" json:"source"`
+	LegalBasisVersion string    `db:"legal_basis_version" json:"legal_basis_version"`
+	RecordedAt        time.Time `db:"recorded_at" json:"recorded_at"`
+}
+
+// ConsentChanged payload of EventConsentChanged. PreviousStatus is empty
+// for the first record of a purpose.
+type ConsentChanged struct {
+	CustomerID        uuid.UUID `json:"customer_id"`
+	Purpose           string    `json:"purpose"`
+	Status            string    `json:"status"`
+	PreviousStatus    string    `json:"previous_status,omitempty"`
+	Source            string    `json:"source"`
+	LegalBasisVersion string    `json:"legal_basis_version"`
+	RecordedAt        time.Time `json:"recorded_at"`
+}
@@ -7,14 +7,16 @@ import (
 )
 
 type Customer struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	FirstName string    `db:"first_name" json:"first_name"`
-	LastName  string    `db:"last_name" json:"last_name"`
-	Gender    string    `db:"gender" json:"gender"`
-	Timezone  string    `db:"timezone" json:"timezone"`
-	Birthday  time.Time `db:"birthday" json:"birthday"`
-	UserID    uuid.UUID `db:"user_id" json:"user_id"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	ID         uuid.UUID  `db:"id" json:"id"`
+	FirstName  string     `db:"first_name" json:"first_name"`
+	LastName   string     `db:"last_name" json:"last_name"`
+	Gender     string     `db:"gender" json:"gender"`
+	Timezone   string     `db:"timezone" json:"timezone"`
+	Birthday   time.Time  `db:"birthday" json:"birthday"`
+	UserID     uuid.UUID  `db:"user_id" json:"user_id"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	ErasedAt   *time.Time `db:"erased_at" json:"erased_at,omitempty"`
+	Attributes Attributes `db:"attributes" json:"attributes,omitempty"`
 }
 
 type CustomerAddress struct {
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DSARQueued    = "queued"
+	DSARRunning   = "running"
+	DSARCompleted = "completed"
+	DSARFailed    = "failed"
+	DSARExpired   = "expired"
+)
+
+type DSARRequest struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	CustomerID  uuid.UUID  `db:"customer_id" json:"customer_id"`
+	Status      string     `db:"status" json:"status"`
+	Error       *string    `db:"error" json:"error,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+	FinishedAt  *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+	DownloadURL string     `db:"-" json:"download_url,omitempty"`
+	URLExpires  *time.Time `db:"-" json:"download_url_expires_at,omitempty"`
+}
+
+// CustomerChange row of customer change history, data is the full row as JSON.
+type CustomerChange struct {
+	ID         int64            `db:"id" json:"id"`
+	CustomerID uuid.UUID        `db:"customer_id" json:"customer_id"`
+	Operation  string           `db:"operation" json:"operation"`
+	OldData    *json.RawMessage `db:"old_data" json:"old_data,omitempty"`
+	NewData    *json.RawMessage `db:"new_data" json:"new_data,omitempty"`
+	ChangedAt  time.Time        `db:"changed_at" json:"changed_at"`
+}
+
+// CustomerData everything stored about a customer, consistent snapshot.
+type CustomerData struct {
+	Customer  Customer           `json:"customer"`
+	Addresses []CustomerAddress  `json:"addresses"`
+	Favorites []Favorite         `json:"favorites"`
+	Changes   []CustomerChange   `json:"changes"`
+	Consents  []Consent          `json:"consents"`
+	Tags      []CustomerTag      `json:"tags"`
+	Greetings []BirthdayGreeting `json:"birthday_greetings"`
+	// Preferences as set by the customer, nil when defaults apply
+	Preferences *StoredPreferences `json:"preferences"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErasureCertificate proof that customer PII was erased.
+type ErasureCertificate struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	CustomerID uuid.UUID `db:"customer_id" json:"customer_id"`
+	Actor      string    `db:"actor" json:"actor"`
+	Reason     string    `db:"reason" json:"reason"`
+	ErasedAt   time.Time `db:"erased_at" json:"erased_at"`
+}
+
+// CustomerErased payload of EventCustomerErased. UserID lets downstream
+// services find their own records, it is not kept on the customer.
+type CustomerErased struct {
+	CustomerID    uuid.UUID `json:"customer_id"`
+	UserID        uuid.UUID `json:"user_id"`
+	CertificateID uuid.UUID `json:"certificate_id"`
+	ErasedAt      time.Time `json:"erased_at"`
+}
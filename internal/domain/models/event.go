@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// event types
+const (
+	EventCustomerErased = "customer.erased"
+	EventConsentChanged = "customer.consent_changed"
+)
+
+// Event domain event stored in the outbox until published.
+type Event struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	Type        string          `db:"type" json:"type"`
+	AggregateID uuid.UUID       `db:"aggregate_id" json:"aggregate_id"`
+	Payload     json.RawMessage `db:"payload" json:"payload"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+}
+
+// NewEvent builds event with payload marshalled to JSON.
+func NewEvent(eventType string, aggregateID uuid.UUID, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		ID:          uuid.New(),
+		Type:        eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+		CreatedAt:   time.Now(),
+	}, nil
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Gender value of the gender reference enumeration. Inactive values stay
+// valid for stored customers but are not accepted on writes.
+type Gender struct {
+	Code      string    `db:"code" json:"code"`
+	Label     string    `db:"label" json:"label"`
+	Position  int       `db:"position" json:"position"`
+	Active    bool      `db:"active" json:"active"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
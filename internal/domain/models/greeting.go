@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BirthdayGreeting greeting sent to a customer for the birthday in Year,
+// Year is taken in the customer's timezone.
+type BirthdayGreeting struct {
+	CustomerID uuid.UUID `db:"customer_id" json:"customer_id"`
+	Year       int       `db:"year" json:"year"`
+	SentAt     time.Time `db:"sent_at" json:"sent_at"`
+}
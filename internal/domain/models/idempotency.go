@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+const (
+	IdempotencyProcessing = "processing"
+	IdempotencyCompleted  = "completed"
+)
+
+type IdempotencyKey struct {
+	Key                 string    `db:"key"`
+	Client              string    `db:"client"`
+	Fingerprint         string    `db:"fingerprint"`
+	Status              string    `db:"status"`
+	ResponseCode        *int      `db:"response_code"`
+	ResponseContentType *string   `db:"response_content_type"`
+	ResponseBody        []byte    `db:"response_body"`
+	CreatedAt           time.Time `db:"created_at"`
+	ExpiresAt           time.Time `db:"expires_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ImportQueued    = "queued"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+type Import struct {
+	ID           uuid.UUID  `db:"id" json:"id"`
+	Status       string     `db:"status" json:"status"`
+	Format       string     `db:"format" json:"format"`
+	DryRun       bool       `db:"dry_run" json:"dry_run"`
+	TotalRows    int        `db:"total_rows" json:"total_rows"`
+	ImportedRows int        `db:"imported_rows" json:"imported_rows"`
+	RejectedRows int        `db:"rejected_rows" json:"rejected_rows"`
+	Error        *string    `db:"error" json:"error,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+	FinishedAt   *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+}
+
+// ImportRowError rejected source row. The row itself is not kept, Field
+// names the request field that failed when it is known.
+type ImportRowError struct {
+	ImportID  uuid.UUID `db:"import_id" json:"import_id"`
+	RowNumber int       `db:"row_number" json:"row_number"`
+	Field     string    `db:"field" json:"field"`
+	Error     string    `db:"error" json:"error"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Preferences customer personalization with defaults resolved.
+type Preferences struct {
+	CustomerID           uuid.UUID   `json:"customer_id"`
+	Locale               string      `json:"locale"`
+	Currency             string      `json:"currency"`
+	NotificationChannels []string    `json:"notification_channels"`
+	QuietHours           *QuietHours `json:"quiet_hours,omitempty"`
+	UpdatedAt            *time.Time  `json:"updated_at,omitempty"`
+}
+
+// QuietHours local time range in the customer's timezone, "HH:MM". End
+// before start means the range crosses midnight.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
+}
+
+// Contains reports whether t falls into quiet hours.
+func (q *QuietHours) Contains(t time.Time) bool {
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start, err1 := time.Parse("15:04", q.Start)
+	end, err2 := time.Parse("15:04", q.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	local := t.In(loc)
+	now := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+
+	if from <= to {
+		return now >= from && now < to
+	}
+	return now >= from || now < to
+}
+
+// StoredPreferences preferences as set by customer, nil fields and nil
+// channels fall back to defaults. Timezone is the customer's.
+type StoredPreferences struct {
+	CustomerID           uuid.UUID  `json:"customer_id"`
+	Timezone             string     `json:"-"`
+	Locale               *string    `json:"locale"`
+	Currency             *string    `json:"currency"`
+	NotificationChannels []string   `json:"notification_channels"`
+	QuietHoursStart      *string    `json:"quiet_hours_start"`
+	QuietHoursEnd        *string    `json:"quiet_hours_end"`
+	UpdatedAt            *time.Time `json:"updated_at"`
+}
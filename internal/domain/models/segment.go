@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerTag tag attached to a customer.
+type CustomerTag struct {
+	Tag       string    `db:"tag" json:"tag"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Segment fields rules can test.
+const (
+	SegmentFieldGender         = "gender"
+	SegmentFieldAge            = "age"
+	SegmentFieldTimezone       = "timezone"
+	SegmentFieldCreatedAt      = "created_at"
+	SegmentFieldAttributes     = "attributes"
+	SegmentFieldFavoritesCount = "favorites_count"
+	SegmentFieldTag            = "tag"
+)
+
+// Segment rule operators.
+const (
+	SegmentOpEq      = "eq"
+	SegmentOpIn      = "in"
+	SegmentOpGte     = "gte"
+	SegmentOpLte     = "lte"
+	SegmentOpLt      = "lt"
+	SegmentOpBetween = "between"
+)
+
+// Segment customers matching Rules, evaluated at query time.
+type Segment struct {
+	ID        uuid.UUID   `db:"id" json:"id"`
+	Name      string      `db:"name" json:"name"`
+	Rules     SegmentRule `db:"-" json:"rules"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time   `db:"updated_at" json:"updated_at"`
+}
+
+// SegmentRule either combines nested rules with All, Any or Not, or
+// tests Field with Op against Value. Path selects the attribute for
+// attributes rules, starting with the namespace.
+type SegmentRule struct {
+	All   []SegmentRule   `json:"all,omitempty"`
+	Any   []SegmentRule   `json:"any,omitempty"`
+	Not   *SegmentRule    `json:"not,omitempty"`
+	Field string          `json:"field,omitempty"`
+	Path  []string        `json:"path,omitempty"`
+	Op    string          `json:"op,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
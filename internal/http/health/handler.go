@@ -0,0 +1,42 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"user-service/internal/lib/health"
+)
+
+type Handler struct {
+	checker *health.Checker
+}
+
+func NewHandler(checker *health.Checker) *Handler {
+	return &Handler{checker: checker}
+}
+
+// Liveness GET /healthz
+func (h *Handler) Liveness(w http.ResponseWriter, r *http.Request) {
+	respond(w, h.checker.Liveness(r.Context()))
+}
+
+// Readiness GET /readyz
+func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	respond(w, h.checker.Readiness(r.Context()))
+}
+
+// Startup GET /startupz
+func (h *Handler) Startup(w http.ResponseWriter, r *http.Request) {
+	respond(w, h.checker.Startup(r.Context()))
+}
+
+func respond(w http.ResponseWriter, report health.Report) {
+	code := http.StatusOK
+	if report.Status != health.StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"user-service/internal/lib/auth"
+)
+
+// RequireClientCert rejects requests without verified mTLS client
+// certificate.
+func RequireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			writeError(w, http.StatusUnauthorized, "client certificate required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientCert takes verified mTLS client certificate subject as caller identity.
+func ClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			leaf := r.TLS.VerifiedChains[0][0]
+			r = r.WithContext(auth.WithIdentity(r.Context(), auth.Identity{
+				Subject: leaf.Subject.String(),
+				Source:  auth.SourceClientCert,
+			}))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"net/http"
+
+	"user-service/internal/storage/psql"
+)
+
+// Consistency installs read-your-writes marker into request context,
+// so reads after a write in the same request go to primary.
+func Consistency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(psql.WithConsistency(r.Context())))
+	})
+}
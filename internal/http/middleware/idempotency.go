@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"user-service/internal/domain/models"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyStoreOpTimeout = 5 * time.Second
+)
+
+// IdempotencyStore persists idempotency keys and stored responses.
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, client, key, fingerprint string, ttl, lockTimeout time.Duration) (bool, *models.IdempotencyKey, error)
+	Complete(ctx context.Context, client, key string, code int, contentType string, body []byte) error
+	Release(ctx context.Context, client, key string) error
+}
+
+// Idempotency makes POST requests with Idempotency-Key header safe to retry.
+// Identical retry replays stored response, the same key with a different
+// body gets 422, a retry while the original is still running gets 409.
+// Keys are scoped per client (see clientKey).
+func Idempotency(store IdempotencyStore, ttl, lockTimeout time.Duration, secretKey string, log *slog.Logger) func(http.Handler) http.Handler {
+	const op = "middleware.Idempotency"
+
+	log = log.With(slog.String("op", op))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				writeError(w, http.StatusBadRequest, "Idempotency-Key too long")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+					return
+				}
+				writeError(w, http.StatusBadRequest, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			client := clientKey(r, secretKey)
+			fingerprint := requestFingerprint(r, body)
+
+			reserved, existing, err := store.Reserve(r.Context(), client, key, fingerprint, ttl, lockTimeout)
+			if err != nil {
+				log.ErrorContext(r.Context(), "failed to reserve idempotency key", slog.String("error", err.Error()))
+				writeError(w, http.StatusServiceUnavailable, "failed to process idempotency key")
+				return
+			}
+
+			if !reserved {
+				switch {
+				case existing.Fingerprint != fingerprint:
+					writeError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+				case existing.Status == models.IdempotencyProcessing:
+					writeError(w, http.StatusConflict, "request with this Idempotency-Key is still in progress")
+				default:
+					replay(w, existing)
+				}
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w, code: http.StatusOK}
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				// handler panicked or failed, let the client retry with the same key
+				ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyStoreOpTimeout)
+				defer cancel()
+				if err := store.Release(ctx, client, key); err != nil {
+					log.ErrorContext(ctx, "failed to release idempotency key", slog.String("error", err.Error()))
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.code >= http.StatusInternalServerError {
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyStoreOpTimeout)
+			defer cancel()
+			if err := store.Complete(ctx, client, key, rec.code, rec.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+				log.ErrorContext(ctx, "failed to store idempotent response", slog.String("error", err.Error()))
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+func replay(w http.ResponseWriter, record *models.IdempotencyKey) {
+	if record.ResponseContentType != nil && *record.ResponseContentType != "" {
+		w.Header().Set("Content-Type", *record.ResponseContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+
+	code := http.StatusOK
+	if record.ResponseCode != nil {
+		code = *record.ResponseCode
+	}
+	w.WriteHeader(code)
+	w.Write(record.ResponseBody)
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder writes response through and keeps a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	if !rr.wroteHeader {
+		rr.code = code
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
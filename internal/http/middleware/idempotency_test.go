@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"user-service/internal/domain/models"
+)
+
+// memIdempotencyStore in-memory IdempotencyStore.
+type memIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyKey
+	err     error
+}
+
+func newMemIdempotencyStore() *memIdempotencyStore {
+	return &memIdempotencyStore{records: make(map[string]*models.IdempotencyKey)}
+}
+
+func (s *memIdempotencyStore) Reserve(_ context.Context, client, key, fingerprint string, ttl, _ time.Duration) (bool, *models.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return false, nil, s.err
+	}
+	if rec, ok := s.records[client+"|"+key]; ok && time.Now().Before(rec.ExpiresAt) {
+		cp := *rec
+		return false, &cp, nil
+	}
+	s.records[client+"|"+key] = &models.IdempotencyKey{
+		Key:         key,
+		Client:      client,
+		Fingerprint: fingerprint,
+		Status:      models.IdempotencyProcessing,
+		CreatedAt:   time.Now(),
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	return true, nil, nil
+}
+
+func (s *memIdempotencyStore) Complete(_ context.Context, client, key string, code int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.records[client+"|"+key]
+	rec.Status = models.IdempotencyCompleted
+	rec.ResponseCode = &code
+	rec.ResponseContentType = &contentType
+	rec.ResponseBody = append([]byte(nil), body...)
+	return nil
+}
+
+func (s *memIdempotencyStore) Release(_ context.Context, client, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, client+"|"+key)
+	return nil
+}
+
+// countingHandler answers with code and counts calls.
+type countingHandler struct {
+	calls atomic.Int32
+	code  int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := h.calls.Add(1)
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.code)
+	io.WriteString(w, `{"call":`+strconv.Itoa(int(n))+`,"body":`+string(body)+`}`)
+}
+
+func newIdempotent(store IdempotencyStore, next http.Handler) http.Handler {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return Idempotency(store, time.Hour, time.Minute, "test-secret", log)(next)
+}
+
+func idempotentRequest(method, path, key, body string) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.RemoteAddr = "192.0.2.1:1234"
+	if key != "" {
+		r.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return r
+}
+
+func TestIdempotency(t *testing.T) {
+	type request struct {
+		method, path, key, body string
+	}
+	post := func(key, body string) request { return request{http.MethodPost, "/api/v1/customers", key, body} }
+
+	tests := []struct {
+		name         string
+		code         int
+		first        request
+		second       request
+		wantCode     int
+		wantCalls    int32
+		wantReplayed bool
+		wantBody     string
+	}{
+		{
+			name: "replay", code: http.StatusCreated,
+			first: post("k1", `{"a":1}`), second: post("k1", `{"a":1}`),
+			wantCode: http.StatusCreated, wantCalls: 1, wantReplayed: true,
+			wantBody: `{"call":1,"body":{"a":1}}`,
+		},
+		{
+			name: "client error replayed", code: http.StatusBadRequest,
+			first: post("k1", `{}`), second: post("k1", `{}`),
+			wantCode: http.StatusBadRequest, wantCalls: 1, wantReplayed: true,
+			wantBody: `{"call":1,"body":{}}`,
+		},
+		{
+			name: "fingerprint mismatch on body", code: http.StatusCreated,
+			first: post("k1", `{"a":1}`), second: post("k1", `{"a":2}`),
+			wantCode: http.StatusUnprocessableEntity, wantCalls: 1,
+		},
+		{
+			name: "fingerprint mismatch on path", code: http.StatusCreated,
+			first:    post("k1", `{"a":1}`),
+			second:   request{http.MethodPost, "/api/v1/customers/batch", "k1", `{"a":1}`},
+			wantCode: http.StatusUnprocessableEntity, wantCalls: 1,
+		},
+		{
+			name: "other key runs again", code: http.StatusCreated,
+			first: post("k1", `{"a":1}`), second: post("k2", `{"a":1}`),
+			wantCode: http.StatusCreated, wantCalls: 2,
+			wantBody: `{"call":2,"body":{"a":1}}`,
+		},
+		{
+			name: "server error releases key", code: http.StatusInternalServerError,
+			first: post("k1", `{"a":1}`), second: post("k1", `{"a":1}`),
+			wantCode: http.StatusInternalServerError, wantCalls: 2,
+			wantBody: `{"call":2,"body":{"a":1}}`,
+		},
+		{
+			name: "without key", code: http.StatusCreated,
+			first: post("", `{"a":1}`), second: post("", `{"a":1}`),
+			wantCode: http.StatusCreated, wantCalls: 2,
+			wantBody: `{"call":2,"body":{"a":1}}`,
+		},
+		{
+			name: "not post", code: http.StatusOK,
+			first:    request{http.MethodPut, "/api/v1/customers/1", "k1", `{"a":1}`},
+			second:   request{http.MethodPut, "/api/v1/customers/1", "k1", `{"a":1}`},
+			wantCode: http.StatusOK, wantCalls: 2,
+			wantBody: `{"call":2,"body":{"a":1}}`,
+		},
+		{
+			name: "key too long", code: http.StatusCreated,
+			first: post("", `{}`), second: post(strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`),
+			wantCode: http.StatusBadRequest, wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &countingHandler{code: tt.code}
+			h := newIdempotent(newMemIdempotencyStore(), next)
+
+			for i, req := range []request{tt.first, tt.second} {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, idempotentRequest(req.method, req.path, req.key, req.body))
+				if i == 1 {
+					if w.Code != tt.wantCode {
+						t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+					}
+					if replayed := w.Header().Get(IdempotentReplayedHeader) == "true"; replayed != tt.wantReplayed {
+						t.Fatalf("replayed = %v, want %v", replayed, tt.wantReplayed)
+					}
+					if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+						t.Fatalf("body = %s, want %s", w.Body.String(), tt.wantBody)
+					}
+				}
+			}
+			if got := next.calls.Load(); got != tt.wantCalls {
+				t.Fatalf("handler calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyConcurrent(t *testing.T) {
+	store := newMemIdempotencyStore()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	h := newIdempotent(store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id":"1"}`)
+	}))
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(first, idempotentRequest(http.MethodPost, "/api/v1/customers", "k1", `{}`))
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, idempotentRequest(http.MethodPost, "/api/v1/customers", "k1", `{}`))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status while in progress = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	close(release)
+	<-done
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first status = %d, want %d", first.Code, http.StatusCreated)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, idempotentRequest(http.MethodPost, "/api/v1/customers", "k1", `{}`))
+	if w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("retry after completion = %d replayed %q, want %d replayed", w.Code, w.Header().Get(IdempotentReplayedHeader), http.StatusCreated)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("handler calls = %d, want 1", got)
+	}
+}
+
+func TestIdempotencyStoreUnavailable(t *testing.T) {
+	store := newMemIdempotencyStore()
+	store.err = errors.New("connection refused")
+	next := &countingHandler{code: http.StatusCreated}
+
+	w := httptest.NewRecorder()
+	newIdempotent(store, next).ServeHTTP(w, idempotentRequest(http.MethodPost, "/api/v1/customers", "k1", `{}`))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if next.calls.Load() != 0 {
+		t.Fatal("handler ran without a reserved key")
+	}
+}
@@ -0,0 +1,119 @@
+package attributes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AttributesService interface {
+	RegisterSchema(ctx context.Context, namespace string, schema []byte) (*models.AttributeSchema, error)
+	GetSchema(ctx context.Context, namespace string) (*models.AttributeSchema, error)
+	ListSchemas(ctx context.Context) ([]models.AttributeSchema, error)
+}
+
+type Handler struct {
+	log     *slog.Logger
+	service AttributesService
+}
+
+func NewHandler(log *slog.Logger, service AttributesService) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+type schemasResponse struct {
+	Schemas []models.AttributeSchema `json:"schemas"`
+}
+
+// RegisterSchema PUT /api/v1/admin/attribute-schemas/{namespace}
+// Body is the JSON Schema of namespace values. Returns 201 for a new
+// namespace, 200 when an existing schema is replaced.
+func (h *Handler) RegisterSchema(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.attributes.RegisterSchema"
+
+	log := h.log.With(slog.String("op", op))
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respond.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		respond.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	schema, err := h.service.RegisterSchema(r.Context(), chi.URLParam(r, "namespace"), body)
+	if err != nil {
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to register schema", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to register schema")
+		}
+		return
+	}
+
+	code := http.StatusOK
+	if schema.Version == 1 {
+		code = http.StatusCreated
+	}
+	respond.JSON(w, code, schema)
+}
+
+// GetSchema GET /api/v1/admin/attribute-schemas/{namespace}
+func (h *Handler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.attributes.GetSchema"
+
+	log := h.log.With(slog.String("op", op))
+
+	schema, err := h.service.GetSchema(r.Context(), chi.URLParam(r, "namespace"))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrAttributeSchemaNotFound):
+			respond.Error(w, http.StatusNotFound, "attribute schema not found")
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to get schema", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to get schema")
+		}
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, schema)
+}
+
+// ListSchemas GET /api/v1/admin/attribute-schemas
+func (h *Handler) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.attributes.ListSchemas"
+
+	log := h.log.With(slog.String("op", op))
+
+	schemas, err := h.service.ListSchemas(r.Context())
+	if err != nil {
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to list schemas", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to list schemas")
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, schemasResponse{Schemas: schemas})
+}
@@ -0,0 +1,183 @@
+package consent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+type ConsentService interface {
+	UpdateConsents(ctx context.Context, customerID uuid.UUID, req *dto.UpdateConsentsRequest) ([]models.Consent, error)
+	GetConsents(ctx context.Context, customerID uuid.UUID, history bool) ([]models.Consent, error)
+	ListConsenting(ctx context.Context, purpose string, after uuid.UUID, limit int) ([]models.Consent, error)
+}
+
+type Handler struct {
+	log     *slog.Logger
+	service ConsentService
+}
+
+func NewHandler(log *slog.Logger, service ConsentService) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+type consentsResponse struct {
+	CustomerID uuid.UUID        `json:"customer_id"`
+	Consents   []models.Consent `json:"consents"`
+}
+
+type consentingResponse struct {
+	Purpose   string           `json:"purpose"`
+	Customers []models.Consent `json:"customers"`
+	NextAfter *uuid.UUID       `json:"next_after,omitempty"`
+}
+
+// GetConsents GET /api/v1/customers/{id}/consents
+// Returns the current consent per purpose, ?history=true returns every record.
+func (h *Handler) GetConsents(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.consent.GetConsents"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	history, _ := strconv.ParseBool(r.URL.Query().Get("history"))
+
+	consents, err := h.service.GetConsents(r.Context(), id, history)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			respond.Error(w, http.StatusNotFound, "customer not found")
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to get consents", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to get consents")
+		}
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, consentsResponse{CustomerID: id, Consents: consents})
+}
+
+// UpdateConsents PUT /api/v1/customers/{id}/consents
+// Records decisions for the given purposes, others are left as they are.
+func (h *Handler) UpdateConsents(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.consent.UpdateConsents"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	var req dto.UpdateConsentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WarnContext(r.Context(), "failed to decode request", slog.String("error", err.Error()))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respond.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		respond.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	consents, err := h.service.UpdateConsents(r.Context(), id, &req)
+	if err != nil {
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+		case errors.Is(err, storage.ErrUserNotFound):
+			respond.Error(w, http.StatusNotFound, "customer not found")
+		case errors.Is(err, storage.ErrCustomerErased):
+			respond.Error(w, http.StatusConflict, "customer is erased")
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to update consents", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to update consents")
+		}
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, consentsResponse{CustomerID: id, Consents: consents})
+}
+
+// ListConsenting GET /api/v1/consents/{purpose}/customers?limit=&after=
+// Customers currently consenting to purpose, paged by customer id:
+// pass next_after of a response as after to get the next page.
+func (h *Handler) ListConsenting(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.consent.ListConsenting"
+
+	log := h.log.With(slog.String("op", op))
+
+	purpose := chi.URLParam(r, "purpose")
+	q := r.URL.Query()
+
+	limit := defaultPageSize
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			respond.Error(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxPageSize))
+			return
+		}
+		limit = n
+	}
+
+	var after uuid.UUID
+	if v := q.Get("after"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			respond.Error(w, http.StatusBadRequest, "invalid after")
+			return
+		}
+		after = id
+	}
+
+	consents, err := h.service.ListConsenting(r.Context(), purpose, after, limit)
+	if err != nil {
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to list consenting customers", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to list consenting customers")
+		}
+		return
+	}
+
+	resp := consentingResponse{Purpose: purpose, Customers: consents}
+	if len(consents) == limit {
+		resp.NextAfter = &consents[len(consents)-1].CustomerID
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
+}
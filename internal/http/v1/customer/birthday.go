@@ -0,0 +1,174 @@
+package customer
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+
+	defaultBirthdayWindow = 7 * 24 * time.Hour
+)
+
+type birthdayResponse struct {
+	customerResponse
+	BirthdayOn string `json:"birthday_on"`
+}
+
+type birthdaysResponse struct {
+	Customers []birthdayResponse `json:"customers"`
+	NextAfter *uuid.UUID         `json:"next_after,omitempty"`
+}
+
+type ageCheckResponse struct {
+	Passed bool `json:"passed"`
+}
+
+// Birthdays GET /api/v1/customers/birthdays?from=&to=&limit=&after=
+// Customers whose birthday in their own timezone overlaps [from, to).
+// from and to are RFC 3339 timestamps or YYYY-MM-DD, a date in to
+// includes the whole day. from defaults to now, to to 7 days after from.
+// birthday_on is the local date, Feb 29 birthdays fall on Feb 28 in
+// common years. Paged by customer id like segment customers.
+func (h *Handler) Birthdays(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.customer.Birthdays"
+
+	log := h.log.With(slog.String("op", op))
+
+	q := r.URL.Query()
+
+	filter := dto.BirthdaysFilter{From: time.Now()}
+	if v := q.Get("from"); v != "" {
+		t, _, err := parseTime(v)
+		if err != nil {
+			respond.Error(w, http.StatusBadRequest, "from must be RFC 3339 timestamp or YYYY-MM-DD")
+			return
+		}
+		filter.From = t
+	}
+	filter.To = filter.From.Add(defaultBirthdayWindow)
+	if v := q.Get("to"); v != "" {
+		t, dateOnly, err := parseTime(v)
+		if err != nil {
+			respond.Error(w, http.StatusBadRequest, "to must be RFC 3339 timestamp or YYYY-MM-DD")
+			return
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.To = t
+	}
+
+	limit := defaultPageSize
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			respond.Error(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxPageSize))
+			return
+		}
+		limit = n
+	}
+
+	var after uuid.UUID
+	if v := q.Get("after"); v != "" {
+		a, err := uuid.Parse(v)
+		if err != nil {
+			respond.Error(w, http.StatusBadRequest, "invalid after")
+			return
+		}
+		after = a
+	}
+
+	matches, err := h.service.BirthdayCustomers(r.Context(), filter, after, limit)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to get birthdays", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to get birthdays")
+		return
+	}
+
+	now := time.Now()
+	resp := birthdaysResponse{Customers: make([]birthdayResponse, len(matches))}
+	for i := range matches {
+		resp.Customers[i] = birthdayResponse{
+			customerResponse: newCustomerResponse(&matches[i].Customer, now),
+			BirthdayOn:       matches[i].On.Format(time.DateOnly),
+		}
+	}
+	if len(matches) == limit {
+		resp.NextAfter = &matches[len(matches)-1].Customer.ID
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
+}
+
+// AgeCheck GET /api/v1/customers/{id}/age-check?min=18
+// Returns only whether customer is at least min years old, so the
+// birthday is not exposed.
+func (h *Handler) AgeCheck(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.customer.AgeCheck"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	minAge, err := strconv.Atoi(r.URL.Query().Get("min"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "min is required and must be an integer")
+		return
+	}
+
+	passed, err := h.service.CheckAge(r.Context(), id, minAge)
+	if err != nil {
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+		case errors.Is(err, storage.ErrUserNotFound):
+			respond.Error(w, http.StatusNotFound, "customer not found")
+		case errors.Is(err, storage.ErrCustomerErased):
+			respond.Error(w, http.StatusConflict, "customer is erased")
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to check age", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to check age")
+		}
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, ageCheckResponse{Passed: passed})
+}
+
+// parseTime parses RFC 3339 timestamp or YYYY-MM-DD date as UTC
+// midnight, dateOnly reports the latter.
+func parseTime(v string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err = time.Parse(time.DateOnly, v)
+	return t, err == nil, err
+}
@@ -0,0 +1,90 @@
+package customer
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/lib/auth"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// EraseCustomer POST /api/v1/customers/{id}/erasure
+// Actor defaults to the authenticated caller when not given in body.
+func (h *Handler) EraseCustomer(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.customer.EraseCustomer"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	var req dto.EraseCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WarnContext(r.Context(), "failed to decode request", slog.String("error", err.Error()))
+		respondWithDecodeError(w, err)
+		return
+	}
+	if identity, ok := auth.FromContext(r.Context()); ok && req.Actor == "" {
+		req.Actor = identity.Subject
+	}
+
+	cert, err := h.service.EraseCustomer(r.Context(), id, &req)
+	if err != nil {
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+		case errors.Is(err, storage.ErrUserNotFound):
+			respond.Error(w, http.StatusNotFound, "customer not found")
+		case errors.Is(err, storage.ErrCustomerErased):
+			respond.Error(w, http.StatusConflict, "customer is already erased")
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to erase customer", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to erase customer")
+		}
+		return
+	}
+
+	respond.JSON(w, http.StatusCreated, cert)
+}
+
+// GetErasureCertificate GET /api/v1/customers/{id}/erasure
+func (h *Handler) GetErasureCertificate(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.customer.GetErasureCertificate"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	cert, err := h.service.GetErasureCertificate(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrErasureNotFound) {
+			respond.Error(w, http.StatusNotFound, "erasure certificate not found")
+			return
+		}
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to get erasure certificate", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to get erasure certificate")
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, cert)
+}
@@ -0,0 +1,238 @@
+package customer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/service"
+
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+const (
+	// exportFlushRows rows written between explicit flushes to the client
+	exportFlushRows = 500
+	// parquetRowGroupBytes caps memory held by parquet writer per export
+	parquetRowGroupBytes = 8 << 20
+)
+
+// exportEncoder writes customers in one export format.
+type exportEncoder interface {
+	Encode(c *models.Customer) error
+	// Flush pushes buffered rows to the underlying writer.
+	Flush() error
+	// Close writes trailing data, encoder must not be used afterwards.
+	Close() error
+}
+
+type exportFormat struct {
+	contentType string
+	extension   string
+	newEncoder  func(w io.Writer) (exportEncoder, error)
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv":     {"text/csv; charset=utf-8", "csv", newCSVEncoder},
+	"ndjson":  {"application/x-ndjson", "ndjson", newNDJSONEncoder},
+	"parquet": {"application/vnd.apache.parquet", "parquet", newParquetEncoder},
+}
+
+// ExportCustomers GET /api/v1/customers/export?format=csv|ndjson|parquet
+// Accepts the same filters as the listing. Rows are streamed from the
+// database cursor to the client with chunked encoding.
+func (h *Handler) ExportCustomers(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.customer.ExportCustomers"
+
+	log := h.log.With(slog.String("op", op))
+
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "csv"
+	}
+	format, ok := exportFormats[name]
+	if !ok {
+		respond.Error(w, http.StatusBadRequest, "format must be csv, ndjson or parquet")
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// export may take longer than server write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.WarnContext(r.Context(), "failed to clear write deadline", slog.String("error", err.Error()))
+	}
+
+	// response starts with the first row, so errors before it
+	// can still be reported with a proper status
+	var (
+		enc  exportEncoder
+		rows int
+	)
+	start := func() error {
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="customers.`+format.extension+`"`)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+
+		var err error
+		enc, err = format.newEncoder(w)
+		return err
+	}
+
+	err = h.service.ExportCustomers(r.Context(), filter, func(c *models.Customer) error {
+		if enc == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushRows == 0 {
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+		return nil
+	})
+	if err == nil && enc == nil {
+		// nothing matched, still send a valid empty file
+		err = start()
+	}
+	if err == nil {
+		err = enc.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	if r.Context().Err() != nil {
+		log.InfoContext(r.Context(), "client disconnected during export", slog.Int("rows", rows))
+		return
+	}
+	if enc == nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to export customers", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to export customers")
+		return
+	}
+
+	log.ErrorContext(r.Context(), "export interrupted", slog.String("error", err.Error()), slog.Int("rows", rows))
+	// headers are sent already, abort so the client does not get
+	// a truncated file that looks complete
+	panic(http.ErrAbortHandler)
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) (exportEncoder, error) {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"id", "first_name", "last_name", "gender", "timezone", "birthday", "user_id", "created_at"})
+	return &csvEncoder{w: cw}, err
+}
+
+func (e *csvEncoder) Encode(c *models.Customer) error {
+	return e.w.Write([]string{
+		c.ID.String(),
+		c.FirstName,
+		c.LastName,
+		c.Gender,
+		c.Timezone,
+		c.Birthday.Format("2006-01-02"),
+		c.UserID.String(),
+		c.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	return e.Flush()
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer) (exportEncoder, error) {
+	return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+}
+
+func (e *ndjsonEncoder) Encode(c *models.Customer) error {
+	return e.enc.Encode(c)
+}
+
+func (e *ndjsonEncoder) Flush() error { return nil }
+
+func (e *ndjsonEncoder) Close() error { return nil }
+
+type parquetCustomer struct {
+	ID        string `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	FirstName string `parquet:"name=first_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	LastName  string `parquet:"name=last_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Gender    string `parquet:"name=gender, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Timezone  string `parquet:"name=timezone, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Birthday  int32  `parquet:"name=birthday, type=INT32, convertedtype=DATE"`
+	UserID    string `parquet:"name=user_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CreatedAt int64  `parquet:"name=created_at, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+}
+
+type parquetEncoder struct {
+	w *writer.ParquetWriter
+}
+
+func newParquetEncoder(w io.Writer) (exportEncoder, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(parquetCustomer), 1)
+	if err != nil {
+		return nil, err
+	}
+	// row group is buffered in memory until full
+	pw.RowGroupSize = parquetRowGroupBytes
+	return &parquetEncoder{w: pw}, nil
+}
+
+func (e *parquetEncoder) Encode(c *models.Customer) error {
+	return e.w.Write(parquetCustomer{
+		ID:        c.ID.String(),
+		FirstName: c.FirstName,
+		LastName:  c.LastName,
+		Gender:    c.Gender,
+		Timezone:  c.Timezone,
+		Birthday:  int32(c.Birthday.Unix() / 86400),
+		UserID:    c.UserID.String(),
+		CreatedAt: c.CreatedAt.UnixMicro(),
+	})
+}
+
+// Flush is a no-op, flushing mid row group would produce tiny row groups.
+func (e *parquetEncoder) Flush() error { return nil }
+
+func (e *parquetEncoder) Close() error {
+	return e.w.WriteStop()
+}
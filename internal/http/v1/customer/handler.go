@@ -4,33 +4,49 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"user-service/internal/domain/dto"
 	"user-service/internal/domain/models"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/service"
 	"user-service/internal/storage"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
+const attributeFilterPrefix = "attr."
+
 type CustomerService interface {
 	CreateCustomer(ctx context.Context, req *dto.CreateCustomerRequest) (*models.Customer, error)
 	GetCustomer(ctx context.Context, id uuid.UUID) (*models.Customer, error)
-	GetAllCustomers(ctx context.Context) ([]models.Customer, error)
+	GetAllCustomers(ctx context.Context, filter dto.CustomerFilter) ([]models.Customer, error)
+	ExportCustomers(ctx context.Context, filter dto.CustomerFilter, fn func(*models.Customer) error) error
 	UpdateCustomer(ctx context.Context, id uuid.UUID, req *dto.UpdateCustomerRequest) (*models.Customer, error)
+	BatchCustomers(ctx context.Context, req *dto.BatchCustomersRequest) (*dto.BatchCustomersResponse, error)
+	EraseCustomer(ctx context.Context, id uuid.UUID, req *dto.EraseCustomerRequest) (*models.ErasureCertificate, error)
+	GetErasureCertificate(ctx context.Context, id uuid.UUID) (*models.ErasureCertificate, error)
+	BirthdayCustomers(ctx context.Context, filter dto.BirthdaysFilter, after uuid.UUID, limit int) ([]dto.CustomerBirthday, error)
+	CheckAge(ctx context.Context, id uuid.UUID, minAge int) (bool, error)
 }
 
 type Handler struct {
 	log     *slog.Logger
 	service CustomerService
+	prefs   PreferencesService
 }
 
-func NewHandler(log *slog.Logger, service CustomerService) *Handler {
+func NewHandler(log *slog.Logger, service CustomerService, prefs PreferencesService) *Handler {
 	return &Handler{
 		log:     log,
 		service: service,
+		prefs:   prefs,
 	}
 }
 
@@ -41,21 +57,30 @@ func (h *Handler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
 
 	var req dto.CreateCustomerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Warn("failed to decode request", slog.String("error", err.Error()))
-		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.WarnContext(r.Context(), "failed to decode request", slog.String("error", err.Error()))
+		respondWithDecodeError(w, err)
 		return
 	}
 
 	customer, err := h.service.CreateCustomer(r.Context(), &req)
 	if err != nil {
-		log.Error("failed to create customer", slog.String("error", err.Error()))
-		respondWithError(w, http.StatusInternalServerError, "failed to create customer")
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to create customer", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to create customer")
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, customer)
+	respond.JSON(w, http.StatusCreated, newCustomerResponse(customer, time.Now()))
 }
 
+// GetCustomer GET /api/v1/customers/{id}?include=preferences
 func (h *Handler) GetCustomer(w http.ResponseWriter, r *http.Request) {
 	const op = "handler.customer.GetCustomer"
 
@@ -64,23 +89,42 @@ func (h *Handler) GetCustomer(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		log.Warn("invalid customer id", slog.String("error", err.Error()))
-		respondWithError(w, http.StatusBadRequest, "invalid customer id")
+		log.WarnContext(r.Context(), "invalid customer id", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	include, err := parseInclude(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	customer, err := h.service.GetCustomer(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
-			respondWithError(w, http.StatusNotFound, "customer not found")
+			respond.Error(w, http.StatusNotFound, "customer not found")
+			return
+		}
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to get customer", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to get customer")
+		return
+	}
+
+	resp, err := h.withIncludes(r.Context(), []models.Customer{*customer}, include)
+	if err != nil {
+		if respond.StorageError(w, err) {
 			return
 		}
-		log.Error("failed to get customer", slog.String("error", err.Error()))
-		respondWithError(w, http.StatusInternalServerError, "failed to get customer")
+		log.ErrorContext(r.Context(), "failed to load includes", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to get customer")
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, customer)
+	respond.JSON(w, http.StatusOK, resp[0])
 }
 
 func (h *Handler) GetAllCustomers(w http.ResponseWriter, r *http.Request) {
@@ -88,14 +132,44 @@ func (h *Handler) GetAllCustomers(w http.ResponseWriter, r *http.Request) {
 
 	log := h.log.With(slog.String("op", op))
 
-	customers, err := h.service.GetAllCustomers(r.Context())
+	filter, err := parseFilter(r)
 	if err != nil {
-		log.Error("failed to get customers", slog.String("error", err.Error()))
-		respondWithError(w, http.StatusInternalServerError, "failed to get customers")
+		respond.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, customers)
+	include, err := parseInclude(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	customers, err := h.service.GetAllCustomers(r.Context(), filter)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to get customers", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to get customers")
+		return
+	}
+
+	resp, err := h.withIncludes(r.Context(), customers, include)
+	if err != nil {
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to load includes", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to get customers")
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
 }
 
 func (h *Handler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
@@ -106,38 +180,155 @@ func (h *Handler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		log.Warn("invalid customer id", slog.String("error", err.Error()))
-		respondWithError(w, http.StatusBadRequest, "invalid customer id")
+		log.WarnContext(r.Context(), "invalid customer id", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
 		return
 	}
 
 	var req dto.UpdateCustomerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Warn("failed to decode request", slog.String("error", err.Error()))
-		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.WarnContext(r.Context(), "failed to decode request", slog.String("error", err.Error()))
+		respondWithDecodeError(w, err)
 		return
 	}
 
 	customer, err := h.service.UpdateCustomer(r.Context(), id, &req)
 	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
 		if errors.Is(err, storage.ErrUserNotFound) {
-			respondWithError(w, http.StatusNotFound, "customer not found")
+			respond.Error(w, http.StatusNotFound, "customer not found")
+			return
+		}
+		if errors.Is(err, storage.ErrCustomerErased) {
+			respond.Error(w, http.StatusConflict, "customer is erased")
 			return
 		}
-		log.Error("failed to update customer", slog.String("error", err.Error()))
-		respondWithError(w, http.StatusInternalServerError, "failed to update customer")
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to update customer", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to update customer")
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, customer)
+	respond.JSON(w, http.StatusOK, newCustomerResponse(customer, time.Now()))
 }
 
-func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(payload)
+// BatchCustomers POST /api/v1/customers:batch
+// Returns 200 if every operation succeeded, 207 if some failed and
+// 422 if atomic batch was rejected.
+func (h *Handler) BatchCustomers(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.customer.BatchCustomers"
+
+	log := h.log.With(slog.String("op", op))
+
+	var req dto.BatchCustomersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WarnContext(r.Context(), "failed to decode request", slog.String("error", err.Error()))
+		respondWithDecodeError(w, err)
+		return
+	}
+	if atomic := r.URL.Query().Get("atomic"); atomic != "" {
+		req.Atomic = atomic == "true" || atomic == "1"
+	}
+
+	resp, err := h.service.BatchCustomers(r.Context(), &req)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to process batch", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to process batch")
+		return
+	}
+
+	respond.JSON(w, batchStatus(resp), resp)
 }
 
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
+// batchStatus maps batch outcome to response status code.
+func batchStatus(resp *dto.BatchCustomersResponse) int {
+	switch {
+	case resp.Failed > 0 && resp.Atomic:
+		return http.StatusUnprocessableEntity
+	case resp.Failed > 0:
+		return http.StatusMultiStatus
+	}
+	return http.StatusOK
+}
+
+// parseFilter reads list filters from query:
+// gender, timezone, created_after, created_before (RFC 3339 or YYYY-MM-DD),
+// first_name, last_name, search, birthday (YYYY-MM-DD) and
+// attr.<namespace>.<path>=value for custom attributes.
+func parseFilter(r *http.Request) (dto.CustomerFilter, error) {
+	q := r.URL.Query()
+	filter := dto.CustomerFilter{
+		Gender:    q.Get("gender"),
+		Timezone:  q.Get("timezone"),
+		FirstName: q.Get("first_name"),
+		LastName:  q.Get("last_name"),
+		Search:    q.Get("search"),
+	}
+
+	if v := q.Get("birthday"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, errors.New("birthday must be YYYY-MM-DD")
+		}
+		filter.Birthday = &t
+	}
+
+	for name, dst := range map[string]**time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.Parse("2006-01-02", v)
+		}
+		if err != nil {
+			return filter, fmt.Errorf("%s must be RFC 3339 timestamp or YYYY-MM-DD", name)
+		}
+		*dst = &t
+	}
+
+	// sorted so the same query builds the same statement
+	keys := make([]string, 0, len(q))
+	for key := range q {
+		if strings.HasPrefix(key, attributeFilterPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		filter.Attributes = append(filter.Attributes, dto.AttributeFilter{
+			Path:  strings.Split(strings.TrimPrefix(key, attributeFilterPrefix), "."),
+			Value: q.Get(key),
+		})
+	}
+
+	return filter, nil
+}
+
+// respondWithDecodeError writes 413 if body exceeded the limit, 400 otherwise.
+func respondWithDecodeError(w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		respond.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	respond.Error(w, http.StatusBadRequest, "invalid request body")
 }
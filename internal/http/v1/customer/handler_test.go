@@ -0,0 +1,31 @@
+package customer
+
+import (
+	"net/http"
+	"testing"
+
+	"user-service/internal/domain/dto"
+)
+
+func TestBatchStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		resp dto.BatchCustomersResponse
+		want int
+	}{
+		{"all succeeded", dto.BatchCustomersResponse{Succeeded: 3}, http.StatusOK},
+		{"atomic succeeded", dto.BatchCustomersResponse{Atomic: true, Succeeded: 3}, http.StatusOK},
+		{"empty", dto.BatchCustomersResponse{}, http.StatusOK},
+		{"partial", dto.BatchCustomersResponse{Succeeded: 2, Failed: 1}, http.StatusMultiStatus},
+		{"all failed", dto.BatchCustomersResponse{Failed: 3}, http.StatusMultiStatus},
+		{"atomic rejected", dto.BatchCustomersResponse{Atomic: true, Failed: 3}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchStatus(&tt.resp); got != tt.want {
+				t.Fatalf("batchStatus(%+v) = %d, want %d", tt.resp, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package customer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/birthday"
+	"user-service/internal/lib/timezone"
+
+	"github.com/google/uuid"
+)
+
+const includePreferences = "preferences"
+
+type PreferencesService interface {
+	GetManyPreferences(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Preferences, error)
+}
+
+// customerResponse customer with computed fields and related data asked
+// for in ?include=. local_time and utc_offset are omitted when stored
+// timezone cannot be loaded, age of erased customers is omitted.
+type customerResponse struct {
+	*models.Customer
+	Age         *int                `json:"age,omitempty"`
+	LocalTime   *time.Time          `json:"local_time,omitempty"`
+	UTCOffset   string              `json:"utc_offset,omitempty"`
+	Preferences *models.Preferences `json:"preferences,omitempty"`
+}
+
+func newCustomerResponse(c *models.Customer, now time.Time) customerResponse {
+	res := customerResponse{Customer: c}
+	local := now.UTC()
+	if loc, err := timezone.Load(c.Timezone); err == nil {
+		local = now.In(loc).Truncate(time.Second)
+		res.LocalTime = &local
+		res.UTCOffset = timezone.Offset(local)
+	}
+	if c.ErasedAt == nil {
+		age := birthday.Age(c.Birthday, local)
+		res.Age = &age
+	}
+	return res
+}
+
+// parseInclude reads comma separated ?include=, unknown values are an error.
+func parseInclude(r *http.Request) (map[string]bool, error) {
+	include := make(map[string]bool)
+	v := r.URL.Query().Get("include")
+	if v == "" {
+		return include, nil
+	}
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case includePreferences:
+			include[name] = true
+		default:
+			return nil, fmt.Errorf("unknown include %q", name)
+		}
+	}
+	return include, nil
+}
+
+// withIncludes wraps customers into responses and loads included data
+// with one query per relation.
+func (h *Handler) withIncludes(ctx context.Context, customers []models.Customer, include map[string]bool) ([]customerResponse, error) {
+	now := time.Now()
+	res := make([]customerResponse, len(customers))
+	for i := range customers {
+		res[i] = newCustomerResponse(&customers[i], now)
+	}
+
+	if include[includePreferences] && len(customers) > 0 {
+		ids := make([]uuid.UUID, len(customers))
+		for i, c := range customers {
+			ids[i] = c.ID
+		}
+		prefs, err := h.prefs.GetManyPreferences(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range res {
+			res[i].Preferences = prefs[res[i].ID]
+		}
+	}
+
+	return res, nil
+}
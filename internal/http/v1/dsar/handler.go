@@ -0,0 +1,144 @@
+package dsar
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/lib/signedurl"
+	dsarService "user-service/internal/service/dsar"
+	"user-service/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type DSARService interface {
+	Request(ctx context.Context, customerID uuid.UUID) (*models.DSARRequest, error)
+	GetRequest(ctx context.Context, id uuid.UUID) (*models.DSARRequest, error)
+	OpenArchive(ctx context.Context, id uuid.UUID, query url.Values) (*os.File, error)
+}
+
+type Handler struct {
+	log     *slog.Logger
+	service DSARService
+}
+
+func NewHandler(log *slog.Logger, service DSARService) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// CreateRequest POST /api/v1/customers/{id}/dsar
+func (h *Handler) CreateRequest(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.dsar.CreateRequest"
+
+	log := h.log.With(slog.String("op", op))
+
+	customerID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	req, err := h.service.Request(r.Context(), customerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			respond.Error(w, http.StatusNotFound, "customer not found")
+		case errors.Is(err, dsarService.ErrQueueFull):
+			w.Header().Set("Retry-After", "60")
+			respond.Error(w, http.StatusServiceUnavailable, "too many requests in progress")
+		default:
+			log.ErrorContext(r.Context(), "failed to create dsar request", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to create dsar request")
+		}
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/dsar/"+req.ID.String())
+	respond.JSON(w, http.StatusAccepted, req)
+}
+
+// GetRequest GET /api/v1/dsar/{id}
+// Completed requests carry a signed download_url valid for a limited time.
+func (h *Handler) GetRequest(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.dsar.GetRequest"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid dsar id")
+		return
+	}
+
+	req, err := h.service.GetRequest(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrDSARNotFound) {
+			respond.Error(w, http.StatusNotFound, "dsar request not found")
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to get dsar request", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to get dsar request")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respond.JSON(w, http.StatusOK, req)
+}
+
+// Download GET /api/v1/dsar/{id}/download?expires=&signature=
+func (h *Handler) Download(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.dsar.Download"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid dsar id")
+		return
+	}
+
+	f, err := h.service.OpenArchive(r.Context(), id, r.URL.Query())
+	if err != nil {
+		switch {
+		case errors.Is(err, signedurl.ErrInvalid):
+			respond.Error(w, http.StatusForbidden, "invalid download link")
+		case errors.Is(err, signedurl.ErrExpired):
+			respond.Error(w, http.StatusGone, "download link expired")
+		case errors.Is(err, storage.ErrDSARNotFound):
+			respond.Error(w, http.StatusNotFound, "dsar request not found")
+		case errors.Is(err, dsarService.ErrNotReady):
+			respond.Error(w, http.StatusGone, "archive is not available")
+		default:
+			log.ErrorContext(r.Context(), "failed to open dsar archive", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to open archive")
+		}
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to stat dsar archive", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to open archive")
+		return
+	}
+
+	// large archives may take longer than server write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="personal-data-`+id.String()+`.zip"`)
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
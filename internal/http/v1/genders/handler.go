@@ -0,0 +1,98 @@
+package genders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type GendersService interface {
+	ListGenders(ctx context.Context, all bool) ([]models.Gender, error)
+	SaveGender(ctx context.Context, code string, req *dto.GenderRequest) (*models.Gender, error)
+}
+
+type Handler struct {
+	log     *slog.Logger
+	service GendersService
+}
+
+func NewHandler(log *slog.Logger, service GendersService) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+type gendersResponse struct {
+	Genders []models.Gender `json:"genders"`
+}
+
+// ListGenders GET /api/v1/genders?all=true
+// Returns values clients can offer ordered by position, all=true adds
+// deactivated ones.
+func (h *Handler) ListGenders(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.genders.ListGenders"
+
+	log := h.log.With(slog.String("op", op))
+
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+
+	genders, err := h.service.ListGenders(r.Context(), all)
+	if err != nil {
+		if respond.StorageError(w, err) {
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to list genders", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to list genders")
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, gendersResponse{Genders: genders})
+}
+
+// SaveGender PUT /api/v1/admin/genders/{code}
+// Adds value to the enumeration or replaces it, "active": false retires
+// a value without touching customers that have it.
+func (h *Handler) SaveGender(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.genders.SaveGender"
+
+	log := h.log.With(slog.String("op", op))
+
+	var req dto.GenderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WarnContext(r.Context(), "failed to decode request", slog.String("error", err.Error()))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respond.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		respond.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	gender, err := h.service.SaveGender(r.Context(), chi.URLParam(r, "code"), &req)
+	if err != nil {
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to save gender", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to save gender")
+		}
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, gender)
+}
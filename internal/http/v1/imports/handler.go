@@ -0,0 +1,239 @@
+package imports
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/http/v1/respond"
+	importService "user-service/internal/service/imports"
+	"user-service/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type ImportService interface {
+	Start(ctx context.Context, params importService.StartParams) (*models.Import, error)
+	GetImport(ctx context.Context, id uuid.UUID) (*models.Import, error)
+	StreamErrors(ctx context.Context, id uuid.UUID, fn func(models.ImportRowError) error) error
+}
+
+type Handler struct {
+	log           *slog.Logger
+	service       ImportService
+	uploadTimeout time.Duration
+}
+
+func NewHandler(log *slog.Logger, service ImportService, uploadTimeout time.Duration) *Handler {
+	return &Handler{
+		log:           log,
+		service:       service,
+		uploadTimeout: uploadTimeout,
+	}
+}
+
+// CreateImport POST /api/v1/imports?format=csv|ndjson&dry_run=true
+// Accepts multipart/form-data with "file" part (optional "mapping" part before it)
+// or the file as raw request body. Optional mapping is a JSON object
+// {"Source column": "first_name"}, also accepted as query parameter.
+func (h *Handler) CreateImport(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.imports.CreateImport"
+
+	log := h.log.With(slog.String("op", op))
+
+	// upload is spooled within the request, server timeouts are sized for
+	// ordinary requests
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(h.uploadTimeout)
+	if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.WarnContext(r.Context(), "failed to extend read deadline", slog.String("error", err.Error()))
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.WarnContext(r.Context(), "failed to extend write deadline", slog.String("error", err.Error()))
+	}
+
+	params := importService.StartParams{
+		Format: r.URL.Query().Get("format"),
+	}
+	params.DryRun, _ = strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	if m := r.URL.Query().Get("mapping"); m != "" {
+		if err := json.Unmarshal([]byte(m), &params.Mapping); err != nil {
+			respond.Error(w, http.StatusBadRequest, "mapping must be a JSON object")
+			return
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			respond.Error(w, http.StatusBadRequest, "invalid multipart body")
+			return
+		}
+		for {
+			part, err := mr.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				respondWithUploadError(w, err)
+				return
+			}
+			if part.FormName() == "mapping" {
+				if err := json.NewDecoder(part).Decode(&params.Mapping); err != nil {
+					respond.Error(w, http.StatusBadRequest, "mapping must be a JSON object")
+					return
+				}
+				continue
+			}
+			if part.FormName() == "file" {
+				if params.Format == "" {
+					params.Format = formatFromName(part.FileName(), part.Header.Get("Content-Type"))
+				}
+				params.Source = part
+				break
+			}
+		}
+		if params.Source == nil {
+			respond.Error(w, http.StatusBadRequest, "file part is required")
+			return
+		}
+	} else {
+		if params.Format == "" {
+			params.Format = formatFromName("", mediaType)
+		}
+		params.Source = r.Body
+	}
+
+	imp, err := h.service.Start(r.Context(), params)
+	if err != nil {
+		switch {
+		case errors.Is(err, importService.ErrUnsupportedFormat):
+			respond.Error(w, http.StatusBadRequest, "format must be csv or ndjson")
+		case errors.Is(err, importService.ErrQueueFull):
+			w.Header().Set("Retry-After", "60")
+			respond.Error(w, http.StatusServiceUnavailable, "too many imports in progress")
+		default:
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				respond.Error(w, http.StatusRequestEntityTooLarge, "upload too large")
+				return
+			}
+			log.ErrorContext(r.Context(), "failed to start import", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to start import")
+		}
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/imports/"+imp.ID.String())
+	respond.JSON(w, http.StatusAccepted, imp)
+}
+
+// GetImport GET /api/v1/imports/{id}
+func (h *Handler) GetImport(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.imports.GetImport"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid import id")
+		return
+	}
+
+	imp, err := h.service.GetImport(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrImportNotFound) {
+			respond.Error(w, http.StatusNotFound, "import not found")
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to get import", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to get import")
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, imp)
+}
+
+// GetImportErrors GET /api/v1/imports/{id}/errors
+// Streams rejected rows as CSV attachment.
+func (h *Handler) GetImportErrors(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.imports.GetImportErrors"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid import id")
+		return
+	}
+
+	var cw *csv.Writer
+	err = h.service.StreamErrors(r.Context(), id, func(e models.ImportRowError) error {
+		if cw == nil {
+			cw = startReport(w, id)
+		}
+		cw.Write([]string{strconv.Itoa(e.RowNumber), e.Field, e.Error})
+		return cw.Error()
+	})
+	if err != nil {
+		if cw != nil {
+			// headers are already sent, nothing else can be reported
+			log.ErrorContext(r.Context(), "error report interrupted", slog.String("error", err.Error()))
+			return
+		}
+		if errors.Is(err, storage.ErrImportNotFound) {
+			respond.Error(w, http.StatusNotFound, "import not found")
+			return
+		}
+		log.ErrorContext(r.Context(), "failed to get import errors", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to get import errors")
+		return
+	}
+
+	if cw == nil {
+		cw = startReport(w, id)
+	}
+	cw.Flush()
+}
+
+func startReport(w http.ResponseWriter, id uuid.UUID) *csv.Writer {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="import-`+id.String()+`-errors.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"row_number", "field", "error"})
+	return cw
+}
+
+func formatFromName(name, contentType string) string {
+	switch {
+	case strings.HasSuffix(name, ".csv"), contentType == "text/csv":
+		return models.ImportFormatCSV
+	case strings.HasSuffix(name, ".ndjson"), strings.HasSuffix(name, ".jsonl"),
+		contentType == "application/x-ndjson", contentType == "application/ndjson":
+		return models.ImportFormatNDJSON
+	}
+	return ""
+}
+
+func respondWithUploadError(w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		respond.Error(w, http.StatusRequestEntityTooLarge, "upload too large")
+		return
+	}
+	respond.Error(w, http.StatusBadRequest, "invalid multipart body")
+}
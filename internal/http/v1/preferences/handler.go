@@ -0,0 +1,109 @@
+package preferences
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type PreferencesService interface {
+	GetPreferences(ctx context.Context, customerID uuid.UUID) (*models.Preferences, error)
+	UpdatePreferences(ctx context.Context, customerID uuid.UUID, req *dto.UpdatePreferencesRequest) (*models.Preferences, error)
+}
+
+type Handler struct {
+	log     *slog.Logger
+	service PreferencesService
+}
+
+func NewHandler(log *slog.Logger, service PreferencesService) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// GetPreferences GET /api/v1/customers/{id}/preferences
+// Unset preferences are filled with configured defaults.
+func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.preferences.GetPreferences"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	prefs, err := h.service.GetPreferences(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			respond.Error(w, http.StatusNotFound, "customer not found")
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to get preferences", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to get preferences")
+		}
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, prefs)
+}
+
+// UpdatePreferences PUT /api/v1/customers/{id}/preferences
+// Replaces all preferences, omitted fields go back to defaults and an
+// empty notification_channels turns notifications off.
+func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.preferences.UpdatePreferences"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	var req dto.UpdatePreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WarnContext(r.Context(), "failed to decode request", slog.String("error", err.Error()))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respond.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		respond.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	prefs, err := h.service.UpdatePreferences(r.Context(), id, &req)
+	if err != nil {
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respond.Error(w, http.StatusBadRequest, validationErr.Error())
+		case errors.Is(err, storage.ErrUserNotFound):
+			respond.Error(w, http.StatusNotFound, "customer not found")
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to update preferences", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to update preferences")
+		}
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, prefs)
+}
@@ -0,0 +1,34 @@
+// Package respond writes JSON responses of v1 handlers.
+package respond
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"user-service/internal/storage"
+)
+
+func JSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func Error(w http.ResponseWriter, code int, message string) {
+	JSON(w, code, map[string]string{"error": message})
+}
+
+// StorageError writes 504/503 for storage timeout/unavailability.
+// Returns false if err is not one of them.
+func StorageError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, storage.ErrQueryTimeout):
+		Error(w, http.StatusGatewayTimeout, "request timed out")
+	case errors.Is(err, storage.ErrUnavailable):
+		Error(w, http.StatusServiceUnavailable, "service temporarily unavailable")
+	default:
+		return false
+	}
+	return true
+}
@@ -1,32 +1,177 @@
 package v1
 
 import (
+	"encoding/json"
 	"log/slog"
+	"net/http"
 
+	"user-service/internal/config"
+	healthHandler "user-service/internal/http/health"
+	mw "user-service/internal/http/middleware"
+	attributesHandler "user-service/internal/http/v1/attributes"
+	consentHandler "user-service/internal/http/v1/consent"
 	customerHandler "user-service/internal/http/v1/customer"
+	dsarHandler "user-service/internal/http/v1/dsar"
+	gendersHandler "user-service/internal/http/v1/genders"
+	importsHandler "user-service/internal/http/v1/imports"
+	preferencesHandler "user-service/internal/http/v1/preferences"
+	segmentHandler "user-service/internal/http/v1/segment"
+	tagsHandler "user-service/internal/http/v1/tags"
+	timezoneHandler "user-service/internal/http/v1/timezone"
+	"user-service/internal/lib/health"
+	"user-service/internal/lib/ratelimit"
+	attributesService "user-service/internal/service/attributes"
+	consentService "user-service/internal/service/consent"
 	customerService "user-service/internal/service/customer"
+	dsarService "user-service/internal/service/dsar"
+	gendersService "user-service/internal/service/genders"
+	importsService "user-service/internal/service/imports"
+	preferencesService "user-service/internal/service/preferences"
+	segmentService "user-service/internal/service/segment"
+	tagsService "user-service/internal/service/tags"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+// SetupProbes registers liveness, readiness and startup probes.
+func SetupProbes(r chi.Router, checker *health.Checker) {
+	healthH := healthHandler.NewHandler(checker)
+
+	r.Get("/healthz", healthH.Liveness)
+	r.Get("/readyz", healthH.Readiness)
+	r.Get("/startupz", healthH.Startup)
+}
+
+// SetupStartupRoutes serves probes while the application starts, every
+// other request is answered with 503 until the API routes are mounted.
+func SetupStartupRoutes(r chi.Router, checker *health.Checker) {
+	r.Use(middleware.Recoverer)
+
+	SetupProbes(r, checker)
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "service is starting"})
+	})
+}
+
 func SetupRoutes(
 	r chi.Router,
 	customerSvc *customerService.Service,
+	importSvc *importsService.Service,
+	dsarSvc *dsarService.Service,
+	consentSvc *consentService.Service,
+	preferencesSvc *preferencesService.Service,
+	attributesSvc *attributesService.Service,
+	tagsSvc *tagsService.Service,
+	segmentSvc *segmentService.Service,
+	gendersSvc *gendersService.Service,
+	checker *health.Checker,
+	cfg *config.Config,
+	limiter *ratelimit.Limiter,
+	idempotencyStore mw.IdempotencyStore,
 	log *slog.Logger,
 ) {
+	root := r
+
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
+	r.Use(mw.Tracing)
+	r.Use(mw.Metrics)
+	r.Use(mw.Consistency)
+	r.Use(mw.ClientCert)
+	r.Use(mw.APIKey(cfg.APIKeys))
+
+	customerH := customerHandler.NewHandler(log, customerSvc, preferencesSvc)
+	importsH := importsHandler.NewHandler(log, importSvc, cfg.Imports.UploadTimeout)
+	dsarH := dsarHandler.NewHandler(log, dsarSvc)
+	consentH := consentHandler.NewHandler(log, consentSvc)
+	preferencesH := preferencesHandler.NewHandler(log, preferencesSvc)
+	attributesH := attributesHandler.NewHandler(log, attributesSvc)
+	tagsH := tagsHandler.NewHandler(log, tagsSvc)
+	segmentH := segmentHandler.NewHandler(log, segmentSvc)
+	timezoneH := timezoneHandler.NewHandler(log)
+	gendersH := gendersHandler.NewHandler(log, gendersSvc)
+
+	SetupProbes(r, checker)
 
-	customerH := customerHandler.NewHandler(log, customerSvc)
+	idempotency := func(r chi.Router) {
+		if idempotencyStore != nil {
+			r.Use(mw.Idempotency(
+				idempotencyStore,
+				cfg.Idempotency.TTL,
+				cfg.Idempotency.LockTimeout,
+				cfg.SecretKey,
+				log,
+			))
+		}
+	}
 
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Route("/customers", func(r chi.Router) {
-			r.Post("/", customerH.CreateCustomer)
-			r.Get("/", customerH.GetAllCustomers)
-			r.Get("/{id}", customerH.GetCustomer)
-			r.Put("/{id}", customerH.UpdateCustomer)
+		if cfg.Server.TLS.Enabled && cfg.Server.TLS.ClientCAFile != "" {
+			r.Use(mw.RequireClientCert)
+		}
+		if limiter != nil {
+			r.Use(mw.RateLimit(limiter, root, cfg.SecretKey, log))
+		}
+
+		// downloads stream for as long as the client reads, no handler deadline
+		r.Get("/customers/export", customerH.ExportCustomers)
+		r.Get("/dsar/{id}/download", dsarH.Download)
+		// uploads are spooled while the client sends, they get their own
+		// limit and deadline
+		r.With(mw.BodyLimit(cfg.Imports.MaxUploadBytes)).Post("/imports", importsH.CreateImport)
+
+		r.Group(func(r chi.Router) {
+			r.Use(mw.Timeout(cfg.Server.Timeout))
+			// body limit goes first, idempotency reads the whole body
+			r.Use(mw.BodyLimit(cfg.Server.MaxBodyBytes))
+			idempotency(r)
+
+			r.Post("/customers:batch", customerH.BatchCustomers)
+			r.Route("/customers", func(r chi.Router) {
+				r.Post("/", customerH.CreateCustomer)
+				r.Get("/", customerH.GetAllCustomers)
+				r.Get("/birthdays", customerH.Birthdays)
+				r.Get("/{id}", customerH.GetCustomer)
+				r.Put("/{id}", customerH.UpdateCustomer)
+				r.Get("/{id}/age-check", customerH.AgeCheck)
+				r.Post("/{id}/dsar", dsarH.CreateRequest)
+				r.Post("/{id}/erasure", customerH.EraseCustomer)
+				r.Get("/{id}/erasure", customerH.GetErasureCertificate)
+				r.Get("/{id}/consents", consentH.GetConsents)
+				r.Put("/{id}/consents", consentH.UpdateConsents)
+				r.Get("/{id}/preferences", preferencesH.GetPreferences)
+				r.Put("/{id}/preferences", preferencesH.UpdatePreferences)
+				r.Get("/{id}/tags", tagsH.GetTags)
+				r.Post("/{id}/tags", tagsH.AddTags)
+				r.Delete("/{id}/tags/{tag}", tagsH.RemoveTag)
+			})
+			r.Get("/dsar/{id}", dsarH.GetRequest)
+			r.Get("/imports/{id}", importsH.GetImport)
+			r.Get("/imports/{id}/errors", importsH.GetImportErrors)
+			r.Get("/timezones", timezoneH.ListTimezones)
+			r.Get("/genders", gendersH.ListGenders)
+			r.Get("/consents/{purpose}/customers", consentH.ListConsenting)
+			r.Post("/segments:preview", segmentH.PreviewSegment)
+			r.Route("/segments", func(r chi.Router) {
+				r.Post("/", segmentH.CreateSegment)
+				r.Get("/", segmentH.ListSegments)
+				r.Get("/{id}", segmentH.GetSegment)
+				r.Put("/{id}", segmentH.UpdateSegment)
+				r.Delete("/{id}", segmentH.DeleteSegment)
+				r.Get("/{id}/customers", segmentH.SegmentCustomers)
+			})
+			r.Route("/admin/attribute-schemas", func(r chi.Router) {
+				r.Get("/", attributesH.ListSchemas)
+				r.Get("/{namespace}", attributesH.GetSchema)
+				r.Put("/{namespace}", attributesH.RegisterSchema)
+			})
+			r.Put("/admin/genders/{code}", gendersH.SaveGender)
 		})
 	})
 }
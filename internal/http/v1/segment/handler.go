@@ -0,0 +1,268 @@
+package segment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+type SegmentService interface {
+	CreateSegment(ctx context.Context, req *dto.SegmentRequest) (*models.Segment, error)
+	UpdateSegment(ctx context.Context, id uuid.UUID, req *dto.SegmentRequest) (*models.Segment, error)
+	DeleteSegment(ctx context.Context, id uuid.UUID) error
+	GetSegment(ctx context.Context, id uuid.UUID) (*models.Segment, error)
+	ListSegments(ctx context.Context) ([]models.Segment, error)
+	SegmentCustomers(ctx context.Context, id, after uuid.UUID, limit int) ([]models.Customer, error)
+	PreviewSegment(ctx context.Context, req *dto.PreviewSegmentRequest) (int64, error)
+}
+
+type Handler struct {
+	log     *slog.Logger
+	service SegmentService
+}
+
+func NewHandler(log *slog.Logger, service SegmentService) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+type segmentsResponse struct {
+	Segments []models.Segment `json:"segments"`
+}
+
+type segmentCustomersResponse struct {
+	SegmentID uuid.UUID         `json:"segment_id"`
+	Customers []models.Customer `json:"customers"`
+	NextAfter *uuid.UUID        `json:"next_after,omitempty"`
+}
+
+type previewResponse struct {
+	Count int64 `json:"count"`
+}
+
+// CreateSegment POST /api/v1/segments
+func (h *Handler) CreateSegment(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.segment.CreateSegment"
+
+	log := h.log.With(slog.String("op", op))
+
+	var req dto.SegmentRequest
+	if !decode(w, r, log, &req) {
+		return
+	}
+
+	segment, err := h.service.CreateSegment(r.Context(), &req)
+	if err != nil {
+		h.respondWithServiceError(w, r, log, err, "failed to create segment")
+		return
+	}
+
+	respond.JSON(w, http.StatusCreated, segment)
+}
+
+// UpdateSegment PUT /api/v1/segments/{id}
+// Replaces name and rules.
+func (h *Handler) UpdateSegment(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.segment.UpdateSegment"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid segment id")
+		return
+	}
+
+	var req dto.SegmentRequest
+	if !decode(w, r, log, &req) {
+		return
+	}
+
+	segment, err := h.service.UpdateSegment(r.Context(), id, &req)
+	if err != nil {
+		h.respondWithServiceError(w, r, log, err, "failed to update segment")
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, segment)
+}
+
+// DeleteSegment DELETE /api/v1/segments/{id}
+func (h *Handler) DeleteSegment(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.segment.DeleteSegment"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid segment id")
+		return
+	}
+
+	if err := h.service.DeleteSegment(r.Context(), id); err != nil {
+		h.respondWithServiceError(w, r, log, err, "failed to delete segment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSegment GET /api/v1/segments/{id}
+func (h *Handler) GetSegment(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.segment.GetSegment"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid segment id")
+		return
+	}
+
+	segment, err := h.service.GetSegment(r.Context(), id)
+	if err != nil {
+		h.respondWithServiceError(w, r, log, err, "failed to get segment")
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, segment)
+}
+
+// ListSegments GET /api/v1/segments
+func (h *Handler) ListSegments(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.segment.ListSegments"
+
+	log := h.log.With(slog.String("op", op))
+
+	segments, err := h.service.ListSegments(r.Context())
+	if err != nil {
+		h.respondWithServiceError(w, r, log, err, "failed to list segments")
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, segmentsResponse{Segments: segments})
+}
+
+// SegmentCustomers GET /api/v1/segments/{id}/customers?limit=&after=
+// Customers currently matching segment rules, paged by customer id:
+// pass next_after of a response as after to get the next page.
+func (h *Handler) SegmentCustomers(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.segment.SegmentCustomers"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid segment id")
+		return
+	}
+
+	q := r.URL.Query()
+
+	limit := defaultPageSize
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			respond.Error(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxPageSize))
+			return
+		}
+		limit = n
+	}
+
+	var after uuid.UUID
+	if v := q.Get("after"); v != "" {
+		a, err := uuid.Parse(v)
+		if err != nil {
+			respond.Error(w, http.StatusBadRequest, "invalid after")
+			return
+		}
+		after = a
+	}
+
+	customers, err := h.service.SegmentCustomers(r.Context(), id, after, limit)
+	if err != nil {
+		h.respondWithServiceError(w, r, log, err, "failed to get segment customers")
+		return
+	}
+
+	resp := segmentCustomersResponse{SegmentID: id, Customers: customers}
+	if len(customers) == limit {
+		resp.NextAfter = &customers[len(customers)-1].ID
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
+}
+
+// PreviewSegment POST /api/v1/segments:preview
+// Counts customers matching rules before the segment is saved.
+func (h *Handler) PreviewSegment(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.segment.PreviewSegment"
+
+	log := h.log.With(slog.String("op", op))
+
+	var req dto.PreviewSegmentRequest
+	if !decode(w, r, log, &req) {
+		return
+	}
+
+	count, err := h.service.PreviewSegment(r.Context(), &req)
+	if err != nil {
+		h.respondWithServiceError(w, r, log, err, "failed to preview segment")
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, previewResponse{Count: count})
+}
+
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error, message string) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respond.Error(w, http.StatusBadRequest, validationErr.Error())
+	case errors.Is(err, storage.ErrSegmentNotFound):
+		respond.Error(w, http.StatusNotFound, "segment not found")
+	case errors.Is(err, storage.ErrSegmentExists):
+		respond.Error(w, http.StatusConflict, "segment with this name already exists")
+	case errors.Is(err, storage.ErrUnsupportedQuery):
+		respond.Error(w, http.StatusUnprocessableEntity, "rules cannot be evaluated: birthday is encrypted")
+	case respond.StorageError(w, err):
+	default:
+		log.ErrorContext(r.Context(), message, slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, message)
+	}
+}
+
+// decode reads JSON body into dst, writes error response and returns
+// false if it fails.
+func decode(w http.ResponseWriter, r *http.Request, log *slog.Logger, dst any) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		log.WarnContext(r.Context(), "failed to decode request", slog.String("error", err.Error()))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respond.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return false
+		}
+		respond.Error(w, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
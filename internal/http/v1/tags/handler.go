@@ -0,0 +1,140 @@
+package tags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/http/v1/respond"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type TagsService interface {
+	GetTags(ctx context.Context, customerID uuid.UUID) ([]string, error)
+	AddTags(ctx context.Context, customerID uuid.UUID, req *dto.TagsRequest) ([]string, error)
+	RemoveTag(ctx context.Context, customerID uuid.UUID, tag string) ([]string, error)
+}
+
+type Handler struct {
+	log     *slog.Logger
+	service TagsService
+}
+
+func NewHandler(log *slog.Logger, service TagsService) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+type tagsResponse struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	Tags       []string  `json:"tags"`
+}
+
+// GetTags GET /api/v1/customers/{id}/tags
+func (h *Handler) GetTags(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.tags.GetTags"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	tags, err := h.service.GetTags(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			respond.Error(w, http.StatusNotFound, "customer not found")
+		case respond.StorageError(w, err):
+		default:
+			log.ErrorContext(r.Context(), "failed to get tags", slog.String("error", err.Error()))
+			respond.Error(w, http.StatusInternalServerError, "failed to get tags")
+		}
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, tagsResponse{CustomerID: id, Tags: tags})
+}
+
+// AddTags POST /api/v1/customers/{id}/tags
+// Adds tags to the ones customer already has.
+func (h *Handler) AddTags(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.tags.AddTags"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	var req dto.TagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WarnContext(r.Context(), "failed to decode request", slog.String("error", err.Error()))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respond.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		respond.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	tags, err := h.service.AddTags(r.Context(), id, &req)
+	if err != nil {
+		h.respondWithWriteError(w, r, log, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, tagsResponse{CustomerID: id, Tags: tags})
+}
+
+// RemoveTag DELETE /api/v1/customers/{id}/tags/{tag}
+// Removing a tag the customer does not have is not an error.
+func (h *Handler) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.tags.RemoveTag"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	tags, err := h.service.RemoveTag(r.Context(), id, chi.URLParam(r, "tag"))
+	if err != nil {
+		h.respondWithWriteError(w, r, log, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, tagsResponse{CustomerID: id, Tags: tags})
+}
+
+func (h *Handler) respondWithWriteError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respond.Error(w, http.StatusBadRequest, validationErr.Error())
+	case errors.Is(err, storage.ErrUserNotFound):
+		respond.Error(w, http.StatusNotFound, "customer not found")
+	case errors.Is(err, storage.ErrCustomerErased):
+		respond.Error(w, http.StatusConflict, "customer is erased")
+	case respond.StorageError(w, err):
+	default:
+		log.ErrorContext(r.Context(), "failed to update tags", slog.String("error", err.Error()))
+		respond.Error(w, http.StatusInternalServerError, "failed to update tags")
+	}
+}
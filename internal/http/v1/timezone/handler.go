@@ -0,0 +1,57 @@
+package timezone
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"user-service/internal/http/v1/respond"
+	tz "user-service/internal/lib/timezone"
+)
+
+type Handler struct {
+	log       *slog.Logger
+	locations []*time.Location
+}
+
+// NewHandler loads all zones once, zones missing in the local database
+// are skipped.
+func NewHandler(log *slog.Logger) *Handler {
+	const op = "handler.timezone.NewHandler"
+
+	h := &Handler{log: log}
+	for _, name := range tz.Zones() {
+		loc, err := tz.Load(name)
+		if err != nil {
+			log.With(slog.String("op", op)).Warn("time zone not found", slog.String("timezone", name))
+			continue
+		}
+		h.locations = append(h.locations, loc)
+	}
+	return h
+}
+
+type zoneResponse struct {
+	Name      string `json:"name"`
+	UTCOffset string `json:"utc_offset"`
+}
+
+type timezonesResponse struct {
+	Timezones []zoneResponse `json:"timezones"`
+}
+
+// ListTimezones GET /api/v1/timezones
+// Returns canonical IANA zone names accepted as customer timezone with
+// their current offset from UTC.
+func (h *Handler) ListTimezones(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	resp := timezonesResponse{Timezones: make([]zoneResponse, len(h.locations))}
+	for i, loc := range h.locations {
+		resp.Timezones[i] = zoneResponse{
+			Name:      loc.String(),
+			UTCOffset: tz.Offset(now.In(loc)),
+		}
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
+}
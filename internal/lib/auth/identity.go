@@ -0,0 +1,28 @@
+package auth
+
+import "context"
+
+// Identity sources.
+const (
+	SourceClientCert = "client_cert"
+	SourceAPIKey     = "api_key"
+)
+
+// Identity authenticated caller.
+type Identity struct {
+	Subject string
+	Source  string
+}
+
+type identityKey struct{}
+
+// WithIdentity returns ctx carrying caller identity.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns caller identity, ok=false for anonymous callers.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
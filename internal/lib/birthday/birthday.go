@@ -0,0 +1,76 @@
+// Package birthday computes ages and birthday dates in customer time zones.
+// Feb 29 birthdays are celebrated on Feb 28 in common years, a full
+// year of age is completed on Mar 1.
+package birthday
+
+import "time"
+
+// MonthDay returns month and day of t as MMDD, 1231 for Dec 31.
+func MonthDay(t time.Time) int16 {
+	return int16(t.Month())*100 + int16(t.Day())
+}
+
+// Age returns full years from birthday to the calendar date of today.
+func Age(birthday, today time.Time) int {
+	age := today.Year() - birthday.Year()
+	if today.Month() < birthday.Month() || today.Month() == birthday.Month() && today.Day() < birthday.Day() {
+		age--
+	}
+	return age
+}
+
+// In returns start of the birthday in year in loc.
+func In(birthday time.Time, year int, loc *time.Location) time.Time {
+	month, day := birthday.Month(), birthday.Day()
+	if month == time.February && day == 29 && !isLeap(year) {
+		day = 28
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// Next returns start of the first birthday in loc that has not ended by
+// from and starts before to.
+func Next(birthday time.Time, loc *time.Location, from, to time.Time) (time.Time, bool) {
+	local := from.In(loc)
+	for year := local.Year() - 1; year <= to.In(loc).Year(); year++ {
+		start := In(birthday, year, loc)
+		if !start.Before(to) {
+			return time.Time{}, false
+		}
+		if start.AddDate(0, 0, 1).After(from) {
+			return start, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// MonthDays returns MMDD values of birthdays that may fall into [from, to)
+// in any time zone: dates of the window are widened by a day on both
+// sides, Feb 29 is included whenever Feb 28 of a common year is.
+func MonthDays(from, to time.Time) []int16 {
+	first := from.UTC().AddDate(0, 0, -1)
+	last := to.UTC().AddDate(0, 0, 1)
+
+	seen := make(map[int16]bool)
+	var mds []int16
+	add := func(md int16) {
+		if !seen[md] {
+			seen[md] = true
+			mds = append(mds, md)
+		}
+	}
+	for d := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC); !d.After(last); d = d.AddDate(0, 0, 1) {
+		add(MonthDay(d))
+		if d.Month() == time.February && d.Day() == 28 && !isLeap(d.Year()) {
+			add(229)
+		}
+		if len(mds) == 366 {
+			break
+		}
+	}
+	return mds
+}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
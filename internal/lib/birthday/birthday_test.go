@@ -0,0 +1,194 @@
+package birthday
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		birthday time.Time
+		today    time.Time
+		want     int
+	}{
+		{"day before birthday", date(1990, 5, 5), date(2024, 5, 4), 33},
+		{"on birthday", date(1990, 5, 5), date(2024, 5, 5), 34},
+		{"earlier month", date(1990, 5, 5), date(2024, 4, 30), 33},
+		{"later month", date(1990, 5, 5), date(2024, 6, 1), 34},
+		{"born today", date(2024, 5, 5), date(2024, 5, 5), 0},
+		{"feb 29 on feb 28 common year", date(2000, 2, 29), date(2001, 2, 28), 0},
+		{"feb 29 on mar 1 common year", date(2000, 2, 29), date(2001, 3, 1), 1},
+		{"feb 29 on feb 28 leap year", date(2000, 2, 29), date(2004, 2, 28), 3},
+		{"feb 29 on feb 29 leap year", date(2000, 2, 29), date(2004, 2, 29), 4},
+		{"dec 31 on jan 1", date(1999, 12, 31), date(2000, 1, 1), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Age(tt.birthday, tt.today); got != tt.want {
+				t.Fatalf("Age() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		birthday time.Time
+		year     int
+		want     time.Time
+	}{
+		{"regular", date(1990, 5, 5), 2024, date(2024, 5, 5)},
+		{"feb 29 leap year", date(2000, 2, 29), 2024, date(2024, 2, 29)},
+		{"feb 29 common year", date(2000, 2, 29), 2023, date(2023, 2, 28)},
+		{"feb 29 century common year", date(2000, 2, 29), 2100, date(2100, 2, 28)},
+		{"feb 29 century leap year", date(2000, 2, 29), 2400, date(2400, 2, 29)},
+		{"feb 28 stays", date(2001, 2, 28), 2024, date(2024, 2, 28)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := In(tt.birthday, tt.year, time.UTC); !got.Equal(tt.want) {
+				t.Fatalf("In() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	honolulu := mustLoad(t, "Pacific/Honolulu")
+
+	tests := []struct {
+		name     string
+		birthday time.Time
+		loc      *time.Location
+		from, to time.Time
+		want     time.Time
+		wantOK   bool
+	}{
+		{
+			name:     "later in window",
+			birthday: date(1990, 5, 5), loc: time.UTC,
+			from: date(2024, 5, 1), to: date(2024, 5, 8),
+			want: date(2024, 5, 5), wantOK: true,
+		},
+		{
+			name:     "outside window",
+			birthday: date(1990, 5, 5), loc: time.UTC,
+			from: date(2024, 5, 6), to: date(2024, 5, 8),
+		},
+		{
+			name:     "in progress at from",
+			birthday: date(1990, 5, 5), loc: time.UTC,
+			from: date(2024, 5, 5).Add(23 * time.Hour), to: date(2024, 5, 8),
+			want: date(2024, 5, 5), wantOK: true,
+		},
+		{
+			name:     "to is exclusive",
+			birthday: date(1990, 5, 5), loc: time.UTC,
+			from: date(2024, 5, 1), to: date(2024, 5, 5),
+		},
+		{
+			name:     "started earlier east of utc",
+			birthday: date(1990, 5, 5), loc: tokyo,
+			from: date(2024, 5, 4).Add(16 * time.Hour), to: date(2024, 5, 5),
+			want: time.Date(2024, 5, 5, 0, 0, 0, 0, tokyo), wantOK: true,
+		},
+		{
+			name:     "already over east of utc",
+			birthday: date(1990, 5, 5), loc: tokyo,
+			from: date(2024, 5, 5).Add(16 * time.Hour), to: date(2024, 5, 6),
+		},
+		{
+			name:     "still going west of utc",
+			birthday: date(1990, 5, 5), loc: honolulu,
+			from: date(2024, 5, 6).Add(5 * time.Hour), to: date(2024, 5, 7),
+			want: time.Date(2024, 5, 5, 0, 0, 0, 0, honolulu), wantOK: true,
+		},
+		{
+			name:     "across year end",
+			birthday: date(1990, 1, 1), loc: time.UTC,
+			from: date(2024, 12, 30), to: date(2025, 1, 3),
+			want: date(2025, 1, 1), wantOK: true,
+		},
+		{
+			name:     "new year eve still going west of utc",
+			birthday: date(1990, 12, 31), loc: honolulu,
+			from: date(2025, 1, 1).Add(5 * time.Hour), to: date(2025, 1, 2),
+			want: time.Date(2024, 12, 31, 0, 0, 0, 0, honolulu), wantOK: true,
+		},
+		{
+			name:     "feb 29 in common year",
+			birthday: date(2000, 2, 29), loc: time.UTC,
+			from: date(2023, 2, 27), to: date(2023, 3, 2),
+			want: date(2023, 2, 28), wantOK: true,
+		},
+		{
+			name:     "feb 29 in leap year",
+			birthday: date(2000, 2, 29), loc: time.UTC,
+			from: date(2024, 2, 27), to: date(2024, 3, 2),
+			want: date(2024, 2, 29), wantOK: true,
+		},
+		{
+			name:     "feb 29 not on feb 28 of leap year",
+			birthday: date(2000, 2, 29), loc: time.UTC,
+			from: date(2024, 2, 28), to: date(2024, 2, 29),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Next(tt.birthday, tt.loc, tt.from, tt.to)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Fatalf("Next() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMonthDays(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []int16
+	}{
+		{"one day widened", date(2024, 5, 5), date(2024, 5, 6), []int16{504, 505, 506, 507}},
+		{"across year end", date(2024, 12, 31), date(2025, 1, 1), []int16{1230, 1231, 101, 102}},
+		{"feb 28 of common year adds 229", date(2023, 2, 28), date(2023, 3, 1), []int16{227, 228, 229, 301, 302}},
+		{"leap year has 229 by date", date(2024, 2, 29), date(2024, 3, 1), []int16{228, 229, 301, 302}},
+		{"widening reaches feb 28 of common year", date(2023, 3, 1), date(2023, 3, 2), []int16{228, 229, 301, 302, 303}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MonthDays(tt.from, tt.to)
+			slices.Sort(got)
+			want := slices.Clone(tt.want)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Fatalf("MonthDays() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	if got := MonthDays(date(2023, 1, 1), date(2025, 1, 1)); len(got) != 366 {
+		t.Fatalf("MonthDays() over two years = %d values, want 366", len(got))
+	}
+}
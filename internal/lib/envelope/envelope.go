@@ -0,0 +1,200 @@
+package envelope
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var ErrNoDataKey = errors.New("no data key")
+
+// DataKey random AES-256 key stored wrapped by a master key.
+type DataKey struct {
+	ID          int64     `db:"id"`
+	MasterKeyID string    `db:"master_key_id"`
+	Wrapped     []byte    `db:"wrapped_key"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// KeyStore persists wrapped data keys.
+type KeyStore interface {
+	// Latest returns the newest data key or ErrNoDataKey.
+	Latest(ctx context.Context) (*DataKey, error)
+	Get(ctx context.Context, id int64) (*DataKey, error)
+	Create(ctx context.Context, masterKeyID string, wrapped []byte) (*DataKey, error)
+}
+
+// Key unwrapped data key.
+type Key struct {
+	ID   int64
+	aead cipher.AEAD
+}
+
+// Seal encrypts plaintext, aad binds ciphertext to its context
+// (e.g. row id and column) so it cannot be moved elsewhere.
+func (k *Key) Seal(plaintext, aad []byte) ([]byte, error) {
+	return seal(k.aead, plaintext, aad)
+}
+
+func (k *Key) Open(ciphertext, aad []byte) ([]byte, error) {
+	return open(k.aead, ciphertext, aad)
+}
+
+// Cipher envelope encryption: data is encrypted with data keys, data
+// keys are stored wrapped by the keyring master key and cached unwrapped
+// in memory. The newest data key is used for writes and re-read every
+// refresh so rotation done by another process is picked up.
+type Cipher struct {
+	keyring Keyring
+	store   KeyStore
+	refresh time.Duration
+
+	mu       sync.RWMutex
+	keys     map[int64]*Key
+	active   *Key
+	activeAt time.Time
+}
+
+func New(keyring Keyring, store KeyStore, refresh time.Duration) *Cipher {
+	return &Cipher{
+		keyring: keyring,
+		store:   store,
+		refresh: refresh,
+		keys:    make(map[int64]*Key),
+	}
+}
+
+// Active returns data key for new writes, creating the first one if needed.
+func (c *Cipher) Active(ctx context.Context) (*Key, error) {
+	c.mu.RLock()
+	active, at := c.active, c.activeAt
+	c.mu.RUnlock()
+	if active != nil && time.Since(at) < c.refresh {
+		return active, nil
+	}
+
+	dk, err := c.store.Latest(ctx)
+	if errors.Is(err, ErrNoDataKey) {
+		return c.Rotate(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("envelope.Active: %w", err)
+	}
+
+	key, err := c.unwrap(ctx, dk)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.active, c.activeAt = key, time.Now()
+	c.mu.Unlock()
+
+	return key, nil
+}
+
+// Key returns data key by id for decryption.
+func (c *Cipher) Key(ctx context.Context, id int64) (*Key, error) {
+	c.mu.RLock()
+	key, ok := c.keys[id]
+	c.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	dk, err := c.store.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("envelope.Key: %w", err)
+	}
+	return c.unwrap(ctx, dk)
+}
+
+// Rotate creates a new data key wrapped by the current master key and
+// makes it active. Rows are moved to it by re-encryption.
+func (c *Cipher) Rotate(ctx context.Context) (*Key, error) {
+	const op = "envelope.Rotate"
+
+	raw := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	masterKeyID := c.keyring.CurrentKeyID()
+	wrapped, err := c.keyring.Wrap(ctx, masterKeyID, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	dk, err := c.store.Create(ctx, masterKeyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	key := &Key{ID: dk.ID, aead: aead}
+
+	c.mu.Lock()
+	c.keys[key.ID] = key
+	c.active, c.activeAt = key, time.Now()
+	c.mu.Unlock()
+
+	return key, nil
+}
+
+// BlindIndex deterministic keyed hash of value for equality lookups on
+// encrypted column. Column is part of the input so equal values in
+// different columns do not match.
+func (c *Cipher) BlindIndex(column, value string) []byte {
+	h := hmac.New(sha256.New, c.keyring.IndexKey())
+	h.Write([]byte(column))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return h.Sum(nil)[:16]
+}
+
+func (c *Cipher) unwrap(ctx context.Context, dk *DataKey) (*Key, error) {
+	const op = "envelope.unwrap"
+
+	raw, err := c.keyring.Unwrap(ctx, dk.MasterKeyID, dk.Wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("%s: data key %d: %w", op, dk.ID, err)
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: data key %d: %w", op, dk.ID, err)
+	}
+	key := &Key{ID: dk.ID, aead: aead}
+
+	c.mu.Lock()
+	c.keys[key.ID] = key
+	c.mu.Unlock()
+
+	return key, nil
+}
+
+// seal returns nonce || ciphertext.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ct := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ct, aad)
+}
@@ -0,0 +1,290 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStore in-memory KeyStore.
+type memStore struct {
+	mu   sync.Mutex
+	keys []*DataKey
+}
+
+func (s *memStore) Latest(_ context.Context) (*DataKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.keys) == 0 {
+		return nil, ErrNoDataKey
+	}
+	return s.keys[len(s.keys)-1], nil
+}
+
+func (s *memStore) Get(_ context.Context, id int64) (*DataKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, dk := range s.keys {
+		if dk.ID == id {
+			return dk, nil
+		}
+	}
+	return nil, ErrNoDataKey
+}
+
+func (s *memStore) Create(_ context.Context, masterKeyID string, wrapped []byte) (*DataKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dk := &DataKey{ID: int64(len(s.keys) + 1), MasterKeyID: masterKeyID, Wrapped: wrapped, CreatedAt: time.Now()}
+	s.keys = append(s.keys, dk)
+	return dk, nil
+}
+
+func newTestCipher(t *testing.T) (*Cipher, *memStore) {
+	t.Helper()
+	kms, err := NewStubKMS("test-seed-0123456789", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &memStore{}
+	return New(kms, store, time.Minute), store
+}
+
+func TestKeySealOpen(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCipher(t)
+	key, err := c.Active(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aad := []byte("customer-1:first_name")
+	sealed, err := key.Seal([]byte("Alice"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name       string
+		ciphertext []byte
+		aad        []byte
+		want       string
+		wantErr    bool
+	}{
+		{"round trip", sealed, aad, "Alice", false},
+		{"other aad", sealed, []byte("customer-2:first_name"), "", true},
+		{"no aad", sealed, nil, "", true},
+		{"tampered", tampered, aad, "", true},
+		{"shorter than nonce", sealed[:4], aad, "", true},
+		{"empty", nil, aad, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := key.Open(tt.ciphertext, tt.aad)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Open() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("Open() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSealUsesRandomNonce(t *testing.T) {
+	c, _ := newTestCipher(t)
+	key, err := c.Active(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := key.Seal([]byte("same"), nil)
+	b, _ := key.Seal([]byte("same"), nil)
+	if bytes.Equal(a, b) {
+		t.Fatal("Seal() produced equal ciphertexts for equal plaintexts")
+	}
+}
+
+func TestCipherRotate(t *testing.T) {
+	ctx := context.Background()
+	c, store := newTestCipher(t)
+
+	first, err := c.Active(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.keys) != 1 {
+		t.Fatalf("Active() created %d data keys, want 1", len(store.keys))
+	}
+	sealed, err := first.Seal([]byte("Alice"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := c.Rotate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID == first.ID {
+		t.Fatal("Rotate() kept the active key")
+	}
+	if active, _ := c.Active(ctx); active.ID != second.ID {
+		t.Fatalf("Active() = key %d, want %d", active.ID, second.ID)
+	}
+
+	// a fresh cipher has nothing cached and unwraps the old key from store
+	kms, _ := NewStubKMS("test-seed-0123456789", "v2")
+	fresh := New(kms, store, time.Minute)
+	old, err := fresh.Key(ctx, first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := old.Open(sealed, nil); err != nil || string(got) != "Alice" {
+		t.Fatalf("Open() with old key = %q, %v", got, err)
+	}
+	if _, err := fresh.Key(ctx, 42); err == nil {
+		t.Fatal("Key() returned unknown data key")
+	}
+}
+
+func TestCipherWrongMasterKey(t *testing.T) {
+	ctx := context.Background()
+	c, store := newTestCipher(t)
+	if _, err := c.Active(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	kms, _ := NewStubKMS("another-seed-0123456789", "v1")
+	other := New(kms, store, time.Minute)
+	if _, err := other.Active(ctx); err == nil {
+		t.Fatal("Active() unwrapped data key with another master key")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	c, _ := newTestCipher(t)
+	kms, _ := NewStubKMS("another-seed-0123456789", "v1")
+	other := New(kms, &memStore{}, time.Minute)
+
+	a := c.BlindIndex("email", "a@example.com")
+	if len(a) != 16 {
+		t.Fatalf("BlindIndex() length = %d, want 16", len(a))
+	}
+
+	tests := []struct {
+		name  string
+		got   []byte
+		equal bool
+	}{
+		{"deterministic", c.BlindIndex("email", "a@example.com"), true},
+		{"other value", c.BlindIndex("email", "b@example.com"), false},
+		{"other column", c.BlindIndex("phone", "a@example.com"), false},
+		{"column value boundary", c.BlindIndex("emaila", "@example.com"), false},
+		{"other index key", other.BlindIndex("email", "a@example.com"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if bytes.Equal(a, tt.got) != tt.equal {
+				t.Fatalf("BlindIndex() equal = %v, want %v", !tt.equal, tt.equal)
+			}
+		})
+	}
+}
+
+func TestNewStubKMS(t *testing.T) {
+	tests := []struct {
+		name    string
+		seed    string
+		keyID   string
+		wantErr bool
+	}{
+		{"valid", "0123456789abcdef", "v1", false},
+		{"short seed", "0123456789abcde", "v1", true},
+		{"no key id", "0123456789abcdef", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStubKMS(tt.seed, tt.keyID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStubKMS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStubKMSWrapBoundToKeyID(t *testing.T) {
+	ctx := context.Background()
+	kms, _ := NewStubKMS("0123456789abcdef", "v1")
+	wrapped, err := kms.Wrap(ctx, "v1", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kms.Unwrap(ctx, "v2", wrapped); err == nil {
+		t.Fatal("Unwrap() accepted key wrapped under another key id")
+	}
+}
+
+func TestLoadFileKeyring(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	short := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 16))
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{"valid", `{"current": "v1", "keys": {"v1": "` + key + `"}, "index_key": "` + key + `"}`, false},
+		{"not json", `{`, true},
+		{"current missing", `{"current": "v2", "keys": {"v1": "` + key + `"}, "index_key": "` + key + `"}`, true},
+		{"short master key", `{"current": "v1", "keys": {"v1": "` + short + `"}, "index_key": "` + key + `"}`, true},
+		{"master key not base64", `{"current": "v1", "keys": {"v1": "%%"}, "index_key": "` + key + `"}`, true},
+		{"short index key", `{"current": "v1", "keys": {"v1": "` + key + `"}, "index_key": "` + short + `"}`, true},
+		{"no index key", `{"current": "v1", "keys": {"v1": "` + key + `"}}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadFileKeyring(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFileKeyring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := LoadFileKeyring(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("LoadFileKeyring() accepted missing file")
+	}
+}
+
+func TestFileKeyringUnknownKey(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	path := filepath.Join(t.TempDir(), "keys.json")
+	file := `{"current": "v1", "keys": {"v1": "` + key + `"}, "index_key": "` + key + `"}`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	k, err := LoadFileKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := k.Wrap(ctx, "v0", make([]byte, 32)); !errors.Is(err, ErrUnknownMasterKey) {
+		t.Fatalf("Wrap() error = %v, want ErrUnknownMasterKey", err)
+	}
+	if _, err := k.Unwrap(ctx, "v0", nil); !errors.Is(err, ErrUnknownMasterKey) {
+		t.Fatalf("Unwrap() error = %v, want ErrUnknownMasterKey", err)
+	}
+}
@@ -0,0 +1,179 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"user-service/internal/config"
+)
+
+var ErrUnknownMasterKey = errors.New("unknown master key")
+
+// Keyring holds master keys. Master keys only wrap and unwrap data keys,
+// they never encrypt data directly.
+type Keyring interface {
+	// CurrentKeyID master key used to wrap new data keys.
+	CurrentKeyID() string
+	Wrap(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+	// IndexKey HMAC key for blind indexes. It does not rotate with master
+	// keys, changing it requires recomputing every index.
+	IndexKey() []byte
+}
+
+// FileKeyring master keys read from a local JSON file:
+//
+//	{"current": "v2", "keys": {"v1": "<base64>", "v2": "<base64>"}, "index_key": "<base64>"}
+//
+// Keys are 32 random bytes. Old keys stay in the file until rotation
+// has re-encrypted every row wrapped with them.
+type FileKeyring struct {
+	current  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+func LoadFileKeyring(path string) (*FileKeyring, error) {
+	const op = "envelope.LoadFileKeyring"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var file struct {
+		Current  string            `json:"current"`
+		Keys     map[string]string `json:"keys"`
+		IndexKey string            `json:"index_key"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	k := &FileKeyring{current: file.Current, keys: make(map[string]cipher.AEAD)}
+	for id, encoded := range file.Keys {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %q: %w", op, id, err)
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %q: %w", op, id, err)
+		}
+		k.keys[id] = aead
+	}
+	if _, ok := k.keys[k.current]; !ok {
+		return nil, fmt.Errorf("%s: current key %q is not in keys", op, k.current)
+	}
+
+	k.indexKey, err = base64.StdEncoding.DecodeString(file.IndexKey)
+	if err != nil || len(k.indexKey) < 32 {
+		return nil, fmt.Errorf("%s: index_key must be at least 32 base64 encoded bytes", op)
+	}
+
+	return k, nil
+}
+
+func (k *FileKeyring) CurrentKeyID() string { return k.current }
+
+func (k *FileKeyring) IndexKey() []byte { return k.indexKey }
+
+func (k *FileKeyring) Wrap(_ context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMasterKey, keyID)
+	}
+	return seal(aead, dataKey, []byte(keyID))
+}
+
+func (k *FileKeyring) Unwrap(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMasterKey, keyID)
+	}
+	return open(aead, wrapped, []byte(keyID))
+}
+
+// StubKMS stands in for a cloud KMS in development and tests. Master keys
+// are derived from seed and never leave it, callers only get wrap and
+// unwrap operations, as with a real KMS. Rotation is done by changing
+// the current key id, previous ids keep working.
+type StubKMS struct {
+	seed    []byte
+	current string
+}
+
+func NewStubKMS(seed, currentKeyID string) (*StubKMS, error) {
+	if len(seed) < 16 {
+		return nil, errors.New("envelope.NewStubKMS: seed must be at least 16 characters")
+	}
+	if currentKeyID == "" {
+		return nil, errors.New("envelope.NewStubKMS: key id is required")
+	}
+	return &StubKMS{seed: []byte(seed), current: currentKeyID}, nil
+}
+
+func (k *StubKMS) CurrentKeyID() string { return k.current }
+
+func (k *StubKMS) IndexKey() []byte { return k.derive("blind-index") }
+
+func (k *StubKMS) Wrap(_ context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	aead, err := newAEAD(k.derive("master:" + keyID))
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, dataKey, []byte(keyID))
+}
+
+func (k *StubKMS) Unwrap(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, err := newAEAD(k.derive("master:" + keyID))
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, wrapped, []byte(keyID))
+}
+
+func (k *StubKMS) derive(label string) []byte {
+	h := hmac.New(sha256.New, k.seed)
+	h.Write([]byte(label))
+	return h.Sum(nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// NewKeyring returns keyring configured by cfg.Keyring.
+func NewKeyring(cfg config.EncryptionConfig) (Keyring, error) {
+	switch cfg.Keyring {
+	case "file", "":
+		k, err := LoadFileKeyring(cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return k, nil
+	case "kms":
+		k, err := NewStubKMS(cfg.KMSSeed, cfg.KMSKeyID)
+		if err != nil {
+			return nil, err
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("envelope.NewKeyring: unknown keyring %q", cfg.Keyring)
+	}
+}
@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc checks a single dependency.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult result of a single dependency check. Probes are public,
+// Error only carries lifecycle state, dependency errors are logged.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report aggregated probe result.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker holds application lifecycle state and dependency checks
+// for liveness, readiness and startup probes.
+type Checker struct {
+	log     *slog.Logger
+	timeout time.Duration
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+
+	mu        sync.RWMutex
+	readiness []check
+}
+
+func New(log *slog.Logger, timeout time.Duration) *Checker {
+	return &Checker{log: log, timeout: timeout}
+}
+
+// AddReadinessCheck registers dependency checked by readiness probe.
+func (c *Checker) AddReadinessCheck(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readiness = append(c.readiness, check{name: name, fn: fn})
+}
+
+// MarkStarted marks application startup as finished.
+func (c *Checker) MarkStarted() {
+	c.started.Store(true)
+}
+
+// MarkShuttingDown flips readiness to failing, so load balancers
+// stop sending traffic before the server is stopped.
+func (c *Checker) MarkShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Liveness reports whether process is alive. It never touches dependencies.
+func (c *Checker) Liveness(ctx context.Context) Report {
+	return Report{Status: StatusOK}
+}
+
+// Startup reports whether application finished startup.
+func (c *Checker) Startup(ctx context.Context) Report {
+	if !c.started.Load() {
+		return Report{
+			Status: StatusFail,
+			Checks: map[string]CheckResult{"startup": {Status: StatusFail, Error: "application is starting"}},
+		}
+	}
+	return Report{Status: StatusOK}
+}
+
+// Readiness runs all dependency checks concurrently.
+func (c *Checker) Readiness(ctx context.Context) Report {
+	const op = "health.Readiness"
+
+	report := Report{Status: StatusOK, Checks: map[string]CheckResult{}}
+
+	if c.shuttingDown.Load() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = CheckResult{Status: StatusFail, Error: "application is shutting down"}
+		return report
+	}
+	if !c.started.Load() {
+		report.Status = StatusFail
+		report.Checks["startup"] = CheckResult{Status: StatusFail, Error: "application is starting"}
+	}
+
+	c.mu.RLock()
+	checks := make([]check, len(c.readiness))
+	copy(checks, c.readiness)
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, ch := range checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := ch.fn(ctx)
+			res := CheckResult{
+				Status:    StatusOK,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				res.Status = StatusFail
+				c.log.Warn("readiness check failed",
+					slog.String("op", op),
+					slog.String("check", ch.name),
+					slog.String("error", err.Error()),
+				)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[ch.name] = res
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}(ch)
+	}
+	wg.Wait()
+
+	return report
+}
@@ -0,0 +1,445 @@
+// Package jsonschema validates JSON documents against a subset of JSON
+// Schema (draft 2020-12). Schemas using keywords outside of the subset
+// are rejected by Compile rather than silently accepting everything.
+//
+// Supported keywords: type, enum, const, properties, required,
+// additionalProperties, minProperties, maxProperties, items, minItems,
+// maxItems, uniqueItems, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, minLength, maxLength, pattern and format (date,
+// date-time, email). Annotations like title and description are ignored.
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidSchema wraps every schema compile error.
+var ErrInvalidSchema = errors.New("invalid schema")
+
+var types = []string{"null", "boolean", "object", "array", "number", "integer", "string"}
+
+var formats = map[string]func(string) bool{
+	"date": func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	},
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		a, err := mail.ParseAddress(s)
+		return err == nil && a.Address == s
+	},
+}
+
+// annotations are accepted and ignored.
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// Schema compiled schema, safe for concurrent use.
+type Schema struct {
+	// reject is set for boolean schema false
+	reject bool
+
+	types      []string
+	enum       []any
+	constant   *any
+	properties map[string]*Schema
+	required   []string
+	// additional is nil when additional properties are allowed
+	// without constraints
+	additional    *Schema
+	minProperties *int
+	maxProperties *int
+	items         *Schema
+	minItems      *int
+	maxItems      *int
+	uniqueItems   bool
+	minimum       *float64
+	maximum       *float64
+	exclusiveMin  *float64
+	exclusiveMax  *float64
+	minLength     *int
+	maxLength     *int
+	pattern       *regexp.Regexp
+	format        string
+}
+
+// ValidationError document does not match schema at Path, a JSON Pointer.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Compile parses schema document.
+func Compile(doc []byte) (*Schema, error) {
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchema, err.Error())
+	}
+	return compile(v, "")
+}
+
+func compile(v any, path string) (*Schema, error) {
+	switch v := v.(type) {
+	case bool:
+		return &Schema{reject: !v}, nil
+	case map[string]any:
+		s := &Schema{}
+		for key, val := range v {
+			if err := s.set(key, val, path); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	default:
+		return nil, schemaError(path, "schema must be an object or boolean")
+	}
+}
+
+// set applies keyword to s.
+func (s *Schema) set(key string, val any, path string) error {
+	at := path + "/" + key
+	var err error
+
+	switch key {
+	case "type":
+		s.types, err = typeList(val, at)
+	case "enum":
+		list, ok := val.([]any)
+		if !ok || len(list) == 0 {
+			return schemaError(at, "must be a non-empty array")
+		}
+		s.enum = list
+	case "const":
+		s.constant = &val
+	case "properties":
+		props, ok := val.(map[string]any)
+		if !ok {
+			return schemaError(at, "must be an object")
+		}
+		s.properties = make(map[string]*Schema, len(props))
+		for name, p := range props {
+			if s.properties[name], err = compile(p, at+"/"+name); err != nil {
+				return err
+			}
+		}
+	case "required":
+		list, ok := val.([]any)
+		if !ok {
+			return schemaError(at, "must be an array of strings")
+		}
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return schemaError(at, "must be an array of strings")
+			}
+			s.required = append(s.required, name)
+		}
+	case "additionalProperties":
+		if b, ok := val.(bool); ok && b {
+			return nil
+		}
+		s.additional, err = compile(val, at)
+	case "items":
+		s.items, err = compile(val, at)
+	case "uniqueItems":
+		b, ok := val.(bool)
+		if !ok {
+			return schemaError(at, "must be a boolean")
+		}
+		s.uniqueItems = b
+	case "minProperties":
+		s.minProperties, err = count(val, at)
+	case "maxProperties":
+		s.maxProperties, err = count(val, at)
+	case "minItems":
+		s.minItems, err = count(val, at)
+	case "maxItems":
+		s.maxItems, err = count(val, at)
+	case "minLength":
+		s.minLength, err = count(val, at)
+	case "maxLength":
+		s.maxLength, err = count(val, at)
+	case "minimum":
+		s.minimum, err = number(val, at)
+	case "maximum":
+		s.maximum, err = number(val, at)
+	case "exclusiveMinimum":
+		s.exclusiveMin, err = number(val, at)
+	case "exclusiveMaximum":
+		s.exclusiveMax, err = number(val, at)
+	case "pattern":
+		p, ok := val.(string)
+		if !ok {
+			return schemaError(at, "must be a string")
+		}
+		if s.pattern, err = regexp.Compile(p); err != nil {
+			return schemaError(at, "invalid pattern: "+err.Error())
+		}
+	case "format":
+		f, ok := val.(string)
+		if !ok || formats[f] == nil {
+			return schemaError(at, "format must be one of date, date-time, email")
+		}
+		s.format = f
+	default:
+		if !annotations[key] {
+			return schemaError(at, "keyword is not supported")
+		}
+	}
+	return err
+}
+
+// Lookup returns schema of value at path of property names, nil if
+// path is not described by the schema.
+func (s *Schema) Lookup(path []string) *Schema {
+	for _, name := range path {
+		if s == nil {
+			return nil
+		}
+		if p, ok := s.properties[name]; ok {
+			s = p
+		} else {
+			s = s.additional
+		}
+	}
+	return s
+}
+
+// Types returns allowed types, empty when any type is allowed.
+func (s *Schema) Types() []string {
+	if s == nil {
+		return nil
+	}
+	return s.types
+}
+
+// ValidateJSON validates encoded document.
+func (s *Schema) ValidateJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return &ValidationError{Message: "invalid JSON"}
+	}
+	return s.Validate(v)
+}
+
+// Validate validates document decoded by encoding/json into any.
+// Returns first mismatch as *ValidationError.
+func (s *Schema) Validate(v any) error {
+	return s.validate(v, "")
+}
+
+func (s *Schema) validate(v any, path string) error {
+	if s.reject {
+		return &ValidationError{Path: path, Message: "value is not allowed"}
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return is(t, v) }) {
+		return &ValidationError{Path: path, Message: "must be " + strings.Join(s.types, " or ")}
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(e any) bool { return reflect.DeepEqual(e, v) }) {
+		return &ValidationError{Path: path, Message: "must be one of the allowed values"}
+	}
+	if s.constant != nil && !reflect.DeepEqual(*s.constant, v) {
+		return &ValidationError{Path: path, Message: "must be the allowed value"}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		return s.validateObject(v, path)
+	case []any:
+		return s.validateArray(v, path)
+	case float64:
+		return s.validateNumber(v, path)
+	case string:
+		return s.validateString(v, path)
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(v map[string]any, path string) error {
+	for _, name := range s.required {
+		if _, ok := v[name]; !ok {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("property %q is required", name)}
+		}
+	}
+	if s.minProperties != nil && len(v) < *s.minProperties {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must have at least %d properties", *s.minProperties)}
+	}
+	if s.maxProperties != nil && len(v) > *s.maxProperties {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must have at most %d properties", *s.maxProperties)}
+	}
+
+	// sorted so the same document always reports the same error
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		at := path + "/" + escape(name)
+		if p, ok := s.properties[name]; ok {
+			if err := p.validate(v[name], at); err != nil {
+				return err
+			}
+			continue
+		}
+		if s.additional != nil {
+			if s.additional.reject {
+				return &ValidationError{Path: at, Message: "property is not allowed"}
+			}
+			if err := s.additional.validate(v[name], at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateArray(v []any, path string) error {
+	if s.minItems != nil && len(v) < *s.minItems {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must have at least %d items", *s.minItems)}
+	}
+	if s.maxItems != nil && len(v) > *s.maxItems {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must have at most %d items", *s.maxItems)}
+	}
+	if s.uniqueItems {
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if reflect.DeepEqual(v[i], v[j]) {
+					return &ValidationError{Path: path, Message: "items must be unique"}
+				}
+			}
+		}
+	}
+	if s.items != nil {
+		for i, item := range v {
+			if err := s.items.validate(item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateNumber(v float64, path string) error {
+	switch {
+	case s.minimum != nil && v < *s.minimum:
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be >= %v", *s.minimum)}
+	case s.maximum != nil && v > *s.maximum:
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be <= %v", *s.maximum)}
+	case s.exclusiveMin != nil && v <= *s.exclusiveMin:
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be > %v", *s.exclusiveMin)}
+	case s.exclusiveMax != nil && v >= *s.exclusiveMax:
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be < %v", *s.exclusiveMax)}
+	}
+	return nil
+}
+
+func (s *Schema) validateString(v, path string) error {
+	n := utf8.RuneCountInString(v)
+	switch {
+	case s.minLength != nil && n < *s.minLength:
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at least %d characters", *s.minLength)}
+	case s.maxLength != nil && n > *s.maxLength:
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %d characters", *s.maxLength)}
+	case s.pattern != nil && !s.pattern.MatchString(v):
+		return &ValidationError{Path: path, Message: "must match pattern " + s.pattern.String()}
+	case s.format != "" && !formats[s.format](v):
+		return &ValidationError{Path: path, Message: "must be a valid " + s.format}
+	}
+	return nil
+}
+
+// is reports whether v decoded by encoding/json is of JSON Schema type t.
+func is(t string, v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case map[string]any:
+		return t == "object"
+	case []any:
+		return t == "array"
+	case float64:
+		return t == "number" || t == "integer" && v == math.Trunc(v)
+	case string:
+		return t == "string"
+	}
+	return false
+}
+
+func typeList(val any, at string) ([]string, error) {
+	var list []any
+	switch val := val.(type) {
+	case string:
+		list = []any{val}
+	case []any:
+		list = val
+	}
+	if len(list) == 0 {
+		return nil, schemaError(at, "must be a type name or a non-empty array of them")
+	}
+
+	res := make([]string, 0, len(list))
+	for _, item := range list {
+		t, ok := item.(string)
+		if !ok || !slices.Contains(types, t) {
+			return nil, schemaError(at, fmt.Sprintf("unknown type %v", item))
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+func count(val any, at string) (*int, error) {
+	f, ok := val.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, schemaError(at, "must be a non-negative integer")
+	}
+	n := int(f)
+	return &n, nil
+}
+
+func number(val any, at string) (*float64, error) {
+	f, ok := val.(float64)
+	if !ok {
+		return nil, schemaError(at, "must be a number")
+	}
+	return &f, nil
+}
+
+// escape escapes property name for JSON Pointer.
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func schemaError(path, message string) error {
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Errorf("%w: %s: %s", ErrInvalidSchema, path, message)
+}
@@ -0,0 +1,180 @@
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{"empty object", `{}`, false},
+		{"boolean true", `true`, false},
+		{"boolean false", `false`, false},
+		{"annotations", `{"title": "t", "description": "d", "$comment": "c", "default": 1}`, false},
+		{"nested properties", `{"type": "object", "properties": {"a": {"type": "array", "items": {"type": "string"}}}}`, false},
+		{"type list", `{"type": ["string", "null"]}`, false},
+		{"not json", `{`, true},
+		{"schema is a number", `1`, true},
+		{"unsupported keyword", `{"oneOf": [{"type": "string"}]}`, true},
+		{"unsupported keyword nested", `{"properties": {"a": {"$ref": "#/x"}}}`, true},
+		{"unknown type", `{"type": "float"}`, true},
+		{"empty type list", `{"type": []}`, true},
+		{"empty enum", `{"enum": []}`, true},
+		{"required not strings", `{"required": [1]}`, true},
+		{"negative count", `{"minLength": -1}`, true},
+		{"fractional count", `{"maxItems": 1.5}`, true},
+		{"minimum not number", `{"minimum": "1"}`, true},
+		{"invalid pattern", `{"pattern": "("}`, true},
+		{"unknown format", `{"format": "uri"}`, true},
+		{"uniqueItems not boolean", `{"uniqueItems": 1}`, true},
+		{"properties not object", `{"properties": []}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile([]byte(tt.schema))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSchema) {
+				t.Fatalf("Compile() error = %v, want ErrInvalidSchema", err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		doc      string
+		wantPath string // "" with wantErr false means valid
+		wantErr  bool
+	}{
+		{"true accepts anything", `true`, `{"a": [1]}`, "", false},
+		{"false rejects everything", `false`, `null`, "", true},
+
+		{"type match", `{"type": "string"}`, `"x"`, "", false},
+		{"type mismatch", `{"type": "string"}`, `1`, "", true},
+		{"type list", `{"type": ["string", "null"]}`, `null`, "", false},
+		{"integer accepts whole float", `{"type": "integer"}`, `2.0`, "", false},
+		{"integer rejects fraction", `{"type": "integer"}`, `2.5`, "", true},
+		{"number accepts integer", `{"type": "number"}`, `2`, "", false},
+		{"boolean is not integer", `{"type": "integer"}`, `true`, "", true},
+
+		{"enum match", `{"enum": ["a", 1, null]}`, `1`, "", false},
+		{"enum mismatch", `{"enum": ["a", 1]}`, `"b"`, "", true},
+		{"enum object deep equal", `{"enum": [{"a": [1, 2]}]}`, `{"a": [1, 2]}`, "", false},
+		{"const match", `{"const": {"a": 1}}`, `{"a": 1}`, "", false},
+		{"const mismatch", `{"const": "x"}`, `"y"`, "", true},
+		{"const null", `{"const": null}`, `null`, "", false},
+
+		{"required present", `{"required": ["a"]}`, `{"a": null}`, "", false},
+		{"required missing", `{"required": ["a"]}`, `{"b": 1}`, "", true},
+		{"required ignored for non objects", `{"required": ["a"]}`, `"x"`, "", false},
+		{"property mismatch path", `{"properties": {"a": {"type": "string"}}}`, `{"a": 1}`, "/a", true},
+		{"nested path", `{"properties": {"a": {"properties": {"b": {"type": "string"}}}}}`, `{"a": {"b": 1}}`, "/a/b", true},
+		{"pointer escaping", `{"properties": {"a/b~c": {"type": "string"}}}`, `{"a/b~c": 1}`, "/a~1b~0c", true},
+		{"additional false", `{"properties": {"a": {}}, "additionalProperties": false}`, `{"a": 1, "b": 2}`, "/b", true},
+		{"additional true", `{"properties": {"a": {}}, "additionalProperties": true}`, `{"b": 2}`, "", false},
+		{"additional schema", `{"additionalProperties": {"type": "integer"}}`, `{"x": 1.5}`, "/x", true},
+		{"minProperties", `{"minProperties": 2}`, `{"a": 1}`, "", true},
+		{"maxProperties", `{"maxProperties": 1}`, `{"a": 1, "b": 2}`, "", true},
+		{"first error is stable", `{"additionalProperties": false}`, `{"b": 1, "a": 2}`, "/a", true},
+
+		{"items", `{"items": {"type": "string"}}`, `["a", 1]`, "/1", true},
+		{"minItems", `{"minItems": 1}`, `[]`, "", true},
+		{"maxItems", `{"maxItems": 1}`, `[1, 2]`, "", true},
+		{"uniqueItems ok", `{"uniqueItems": true}`, `[1, "1", [1]]`, "", false},
+		{"uniqueItems duplicate", `{"uniqueItems": true}`, `[{"a": 1}, {"a": 1}]`, "", true},
+		{"uniqueItems false allows duplicates", `{"uniqueItems": false}`, `[1, 1]`, "", false},
+
+		{"minimum inclusive", `{"minimum": 1}`, `1`, "", false},
+		{"minimum below", `{"minimum": 1}`, `0.5`, "", true},
+		{"maximum inclusive", `{"maximum": 1}`, `1`, "", false},
+		{"maximum above", `{"maximum": 1}`, `1.1`, "", true},
+		{"exclusiveMinimum boundary", `{"exclusiveMinimum": 1}`, `1`, "", true},
+		{"exclusiveMaximum boundary", `{"exclusiveMaximum": 1}`, `1`, "", true},
+		{"exclusiveMaximum below", `{"exclusiveMaximum": 1}`, `0.99`, "", false},
+		{"number keywords ignore strings", `{"minimum": 1}`, `"0"`, "", false},
+
+		{"minLength counts runes", `{"minLength": 2}`, `"жё"`, "", false},
+		{"maxLength counts runes", `{"maxLength": 2}`, `"жёж"`, "", true},
+		{"pattern match", `{"pattern": "^[a-z]+$"}`, `"abc"`, "", false},
+		{"pattern is not anchored", `{"pattern": "b"}`, `"abc"`, "", false},
+		{"pattern mismatch", `{"pattern": "^[a-z]+$"}`, `"ab1"`, "", true},
+		{"format date", `{"format": "date"}`, `"2024-02-29"`, "", false},
+		{"format date invalid day", `{"format": "date"}`, `"2023-02-29"`, "", true},
+		{"format date-time", `{"format": "date-time"}`, `"2024-01-02T03:04:05+02:00"`, "", false},
+		{"format date-time without zone", `{"format": "date-time"}`, `"2024-01-02T03:04:05"`, "", true},
+		{"format email", `{"format": "email"}`, `"a@example.com"`, "", false},
+		{"format email with name", `{"format": "email"}`, `"A <a@example.com>"`, "", true},
+		{"string keywords ignore numbers", `{"maxLength": 1}`, `12345`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Compile([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			err = s.ValidateJSON([]byte(tt.doc))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("ValidateJSON() error = %T, want *ValidationError", err)
+			}
+			if tt.wantPath != "" && verr.Path != tt.wantPath {
+				t.Fatalf("ValidateJSON() path = %q, want %q", verr.Path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestValidateJSONInvalid(t *testing.T) {
+	s, err := Compile([]byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ValidateJSON([]byte(`{"a":`)); err == nil {
+		t.Fatal("ValidateJSON() accepted invalid JSON")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	s, err := Compile([]byte(`{
+		"properties": {"a": {"properties": {"b": {"type": "integer"}}}},
+		"additionalProperties": {"type": "string"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		path  []string
+		types []string
+	}{
+		{"declared", []string{"a", "b"}, []string{"integer"}},
+		{"additional", []string{"x"}, []string{"string"}},
+		{"undescribed", []string{"a", "c"}, nil},
+		{"below a leaf", []string{"x", "y"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Lookup(tt.path).Types()
+			if len(got) != len(tt.types) || len(got) > 0 && got[0] != tt.types[0] {
+				t.Fatalf("Lookup(%v).Types() = %v, want %v", tt.path, got, tt.types)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "user_service"
+
+// HTTP RED metrics. Route label is a chi route pattern, not the raw path.
+var (
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_errors_total",
+		Help:      "Total number of HTTP requests finished with 5xx status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request duration in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Storage metrics.
+var (
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Repository method query duration in seconds.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"op"})
+
+	MigrationVersion = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "migration_version",
+		Help:      "Current applied database migration version.",
+	})
+
+	MigrationDirty = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "migration_dirty",
+		Help:      "1 if the last migration failed and the schema is dirty.",
+	})
+)
+
+// Business metrics.
+var (
+	CustomersCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "customers_created_total",
+		Help:      "Total number of created customers.",
+	})
+
+	CustomersUpdated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "customers_updated_total",
+		Help:      "Total number of updated customers.",
+	})
+
+	CustomersErased = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "customers_erased_total",
+		Help:      "Total number of customers whose personal data was erased.",
+	})
+)
+
+// RegisterDBStats exposes sql.DB pool stats as gauges.
+func RegisterDBStats(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveQuery records repository query duration. Use with defer:
+//
+//	defer metrics.ObserveQuery(op, time.Now())
+func ObserveQuery(op string, start time.Time) {
+	DBQueryDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
@@ -1,12 +1,19 @@
 package migrator
 
 import (
+	"context"
+	"database/sql"
 	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"strconv"
+	"strings"
 
 	"user-service/internal/config"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/retry"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -15,16 +22,21 @@ import (
 )
 
 //go:embed migrations/*.sql
-var fs embed.FS
+var migrationsFS embed.FS
 
-// RunMigrations up migrations files from embed.FS - fs
-func RunMigrations(cfg config.PostgresConfig, log *slog.Logger) error {
+// RunMigrations up migrations files from embed.FS - migrationsFS
+func RunMigrations(ctx context.Context, cfg config.PostgresConfig, log *slog.Logger) error {
 	const op = "migrator.RunMigrations"
 
 	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DbName, cfg.SslMode)
 
-	migrationDB, err := sqlx.Connect("postgres", connStr)
+	var migrationDB *sqlx.DB
+	err := retry.Do(ctx, cfg.ConnectRetry, log, "postgres migrations", func(ctx context.Context) error {
+		var err error
+		migrationDB, err = sqlx.ConnectContext(ctx, "postgres", connStr)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("%s: failed to connect: %w", op, err)
 	}
@@ -35,7 +47,7 @@ func RunMigrations(cfg config.PostgresConfig, log *slog.Logger) error {
 		return fmt.Errorf("%s: failed to create driver: %w", op, err)
 	}
 
-	source, err := iofs.New(fs, "migrations")
+	source, err := iofs.New(migrationsFS, "migrations")
 	if err != nil {
 		return fmt.Errorf("%s: failed to create source: %w", op, err)
 	}
@@ -51,5 +63,75 @@ func RunMigrations(cfg config.PostgresConfig, log *slog.Logger) error {
 		return fmt.Errorf("%s: migration failed: %w", op, err)
 	}
 
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("%s: failed to get version: %w", op, err)
+	}
+	metrics.MigrationVersion.Set(float64(version))
+	if dirty {
+		metrics.MigrationDirty.Set(1)
+	} else {
+		metrics.MigrationDirty.Set(0)
+	}
+	log.Info("database migrations applied", slog.Uint64("version", uint64(version)), slog.Bool("dirty", dirty))
+
+	return nil
+}
+
+// LatestVersion returns the highest migration version embedded in binary.
+func LatestVersion() (uint, error) {
+	const op = "migrator.LatestVersion"
+
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var latest uint
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		if uint(v) > latest {
+			latest = uint(v)
+		}
+	}
+
+	return latest, nil
+}
+
+// CheckState verifies database schema is clean and up to date.
+func CheckState(ctx context.Context, db *sqlx.DB) error {
+	const op = "migrator.CheckState"
+
+	var state struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+	err := db.GetContext(ctx, &state, `SELECT version, dirty FROM schema_migrations LIMIT 1`)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: no migrations applied", op)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if state.Dirty {
+		return fmt.Errorf("%s: schema is dirty at version %d", op, state.Version)
+	}
+
+	latest, err := LatestVersion()
+	if err != nil {
+		return err
+	}
+	if uint(state.Version) < latest {
+		return fmt.Errorf("%s: schema version %d is behind %d", op, state.Version, latest)
+	}
+
 	return nil
 }
@@ -0,0 +1,16 @@
+-- NULL means the configured default is used
+CREATE TABLE IF NOT EXISTS "customer_preferences" (
+    "customer_id" UUID PRIMARY KEY,
+    "locale" VARCHAR(35),
+    "currency" CHAR(3),
+    "notification_channels" TEXT[],
+    "quiet_hours_start" TIME,
+    "quiet_hours_end" TIME,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT fk_customer_preferences_customer
+        FOREIGN KEY ("customer_id")
+        REFERENCES "customers"("id")
+        ON DELETE CASCADE,
+    CONSTRAINT chk_customer_preferences_quiet_hours
+        CHECK (("quiet_hours_start" IS NULL) = ("quiet_hours_end" IS NULL))
+);
@@ -0,0 +1,13 @@
+-- top level keys are namespaces, each validated against its schema on write
+ALTER TABLE "customers" ADD COLUMN IF NOT EXISTS "attributes" JSONB DEFAULT '{}'::jsonb NOT NULL;
+
+-- jsonb_path_ops serves containment (@>) only, which is all list filters use
+CREATE INDEX IF NOT EXISTS "idx_customers_attributes" ON "customers" USING GIN ("attributes" jsonb_path_ops);
+
+CREATE TABLE IF NOT EXISTS "attribute_schemas" (
+    "namespace" VARCHAR(64) PRIMARY KEY,
+    "schema" JSONB NOT NULL,
+    "version" INTEGER DEFAULT 1 NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
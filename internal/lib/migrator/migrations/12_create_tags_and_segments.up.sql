@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS "customer_tags" (
+    "customer_id" UUID NOT NULL,
+    "tag" VARCHAR(64) NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY ("customer_id", "tag"),
+    CONSTRAINT fk_customer_tags_customer
+        FOREIGN KEY ("customer_id")
+        REFERENCES "customers"("id")
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_customer_tags_tag" ON "customer_tags" ("tag", "customer_id");
+
+-- rules are compiled into SQL on every query, they are not materialized
+CREATE TABLE IF NOT EXISTS "segments" (
+    "id" UUID PRIMARY KEY,
+    "name" VARCHAR(100) NOT NULL UNIQUE,
+    "rules" JSONB NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- favorites_count rules count favorites per customer, the primary key leads with product_id
+CREATE INDEX IF NOT EXISTS "idx_favorites_customer" ON "favorites" ("customer_id");
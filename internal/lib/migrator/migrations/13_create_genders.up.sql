@@ -0,0 +1,32 @@
+-- reference values of customers.gender, inactive values stay valid for
+-- stored customers but are not accepted on writes
+CREATE TABLE IF NOT EXISTS "genders" (
+    "code" VARCHAR(20) PRIMARY KEY,
+    "label" VARCHAR(100) NOT NULL,
+    "position" INT NOT NULL DEFAULT 0,
+    "active" BOOLEAN NOT NULL DEFAULT TRUE,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+INSERT INTO "genders" ("code", "label", "position") VALUES
+    ('male', 'Male', 10),
+    ('female', 'Female', 20),
+    ('non_binary', 'Non-binary', 30),
+    ('prefer_not_to_say', 'Prefer not to say', 40),
+    ('custom', 'Custom', 50)
+ON CONFLICT ("code") DO NOTHING;
+
+-- the hard coded CHECK from the first migration is replaced by the lookup table
+ALTER TABLE "customers" DROP CONSTRAINT IF EXISTS "customers_gender_check";
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_customers_gender') THEN
+        ALTER TABLE "customers"
+            ADD CONSTRAINT fk_customers_gender
+            FOREIGN KEY ("gender")
+            REFERENCES "genders"("code");
+    END IF;
+END
+$$;
@@ -0,0 +1,13 @@
+-- month and day of birthday as MMDD for birthday queries. It is written in
+-- clear next to an encrypted birthday too, the year stays encrypted.
+-- Rows encrypted before this migration are filled by key rotation.
+ALTER TABLE "customers" ADD COLUMN IF NOT EXISTS "birthday_md" SMALLINT;
+
+-- values do not change, keep the backfill out of change history
+SET LOCAL user_service.reencrypting = 'on';
+
+UPDATE "customers"
+SET "birthday_md" = EXTRACT(MONTH FROM "birthday") * 100 + EXTRACT(DAY FROM "birthday")
+WHERE "birthday" IS NOT NULL AND "birthday_md" IS NULL AND "erased_at" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "idx_customers_birthday_md" ON "customers" ("birthday_md") WHERE "erased_at" IS NULL;
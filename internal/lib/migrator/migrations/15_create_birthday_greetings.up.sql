@@ -0,0 +1,12 @@
+-- one greeting per customer and year, the row is claimed before the
+-- notification is sent so a greeting never goes out twice
+CREATE TABLE IF NOT EXISTS "birthday_greetings" (
+    "customer_id" UUID NOT NULL,
+    "year" INT NOT NULL,
+    "sent_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY ("customer_id", "year"),
+    CONSTRAINT fk_birthday_greetings_customer
+        FOREIGN KEY ("customer_id")
+        REFERENCES "customers"("id")
+        ON DELETE CASCADE
+);
@@ -0,0 +1,16 @@
+-- change history recorded before a customer was encrypted still holds the
+-- plaintext columns, key rotation scrubs rows it encrypts from now on
+UPDATE "customer_changes" ch
+SET "old_data" = ch."old_data" - s.keys,
+    "new_data" = ch."new_data" - s.keys
+FROM (
+    SELECT "id", array_remove(ARRAY[
+        CASE WHEN "first_name" IS NULL THEN 'first_name' END,
+        CASE WHEN "last_name" IS NULL THEN 'last_name' END,
+        CASE WHEN "birthday" IS NULL THEN 'birthday' END
+    ], NULL) AS keys
+    FROM "customers"
+    WHERE "data_key_id" IS NOT NULL
+) s
+WHERE ch."customer_id" = s."id"
+    AND (ch."old_data" ?| s.keys OR ch."new_data" ?| s.keys);
@@ -0,0 +1,4 @@
+-- rejected rows were kept as raw source text with customer data in clear,
+-- error reports keep the row number, the failing field and the reason only
+ALTER TABLE "import_errors" DROP COLUMN IF EXISTS "raw";
+ALTER TABLE "import_errors" ADD COLUMN IF NOT EXISTS "field" VARCHAR(50) NOT NULL DEFAULT '';
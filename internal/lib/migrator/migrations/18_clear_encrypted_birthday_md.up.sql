@@ -0,0 +1,20 @@
+-- month and day of an encrypted birthday are no longer kept in clear
+-- unless encryption.birthday_month_day is set, key rotation fills them
+-- in again when it is
+SET LOCAL user_service.reencrypting = 'on';
+
+UPDATE "customers"
+SET "birthday_md" = NULL
+WHERE "birthday" IS NULL AND "birthday_enc" IS NOT NULL AND "birthday_md" IS NOT NULL;
+
+UPDATE "customer_changes" ch
+SET "old_data" = ch."old_data" - 'birthday_md',
+    "new_data" = ch."new_data" - 'birthday_md'
+FROM "customers" c
+WHERE ch."customer_id" = c."id"
+    AND c."birthday_md" IS NULL AND c."birthday_enc" IS NOT NULL
+    AND (ch."old_data" ? 'birthday_md' OR ch."new_data" ? 'birthday_md');
+
+-- birthday queries check these rows one by one
+CREATE INDEX IF NOT EXISTS "idx_customers_birthday_md_missing" ON "customers" ("id")
+    WHERE "birthday_md" IS NULL AND "birthday_enc" IS NOT NULL AND "erased_at" IS NULL;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+    "key" VARCHAR(255) NOT NULL,
+    "client" VARCHAR(255) NOT NULL,
+    "fingerprint" CHAR(64) NOT NULL,
+    "status" VARCHAR(20) NOT NULL CHECK (status IN ('processing', 'completed')),
+    "response_code" INTEGER,
+    "response_content_type" VARCHAR(255),
+    "response_body" BYTEA,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY ("client", "key")
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON "idempotency_keys" ("expires_at");
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS "imports" (
+    "id" UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    "status" VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'running', 'completed', 'failed')),
+    "format" VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'ndjson')),
+    "dry_run" BOOLEAN NOT NULL DEFAULT FALSE,
+    "total_rows" INTEGER NOT NULL DEFAULT 0,
+    "imported_rows" INTEGER NOT NULL DEFAULT 0,
+    "rejected_rows" INTEGER NOT NULL DEFAULT 0,
+    "error" TEXT,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "finished_at" TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS "import_errors" (
+    "import_id" UUID NOT NULL,
+    "row_number" INTEGER NOT NULL,
+    "raw" TEXT NOT NULL,
+    "error" TEXT NOT NULL,
+    PRIMARY KEY ("import_id", "row_number"),
+    CONSTRAINT fk_import_errors_import
+        FOREIGN KEY ("import_id")
+        REFERENCES "imports"("id")
+        ON DELETE CASCADE
+);
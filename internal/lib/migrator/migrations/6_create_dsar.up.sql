@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS "customer_changes" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "customer_id" UUID NOT NULL,
+    "operation" VARCHAR(10) NOT NULL,
+    "old_data" JSONB,
+    "new_data" JSONB,
+    "changed_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT fk_customer_changes_customer
+        FOREIGN KEY ("customer_id")
+        REFERENCES "customers"("id")
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_customer_changes_customer_id" ON "customer_changes" ("customer_id", "changed_at");
+
+-- history is kept by trigger so every write path (API, batch, import COPY) is covered
+CREATE OR REPLACE FUNCTION record_customer_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO customer_changes (customer_id, operation, new_data)
+        VALUES (NEW.id, 'insert', to_jsonb(NEW));
+    ELSIF to_jsonb(OLD) IS DISTINCT FROM to_jsonb(NEW) THEN
+        INSERT INTO customer_changes (customer_id, operation, old_data, new_data)
+        VALUES (NEW.id, 'update', to_jsonb(OLD), to_jsonb(NEW));
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "customers_record_change" ON "customers";
+CREATE TRIGGER "customers_record_change"
+    AFTER INSERT OR UPDATE ON "customers"
+    FOR EACH ROW EXECUTE FUNCTION record_customer_change();
+
+CREATE TABLE IF NOT EXISTS "dsar_requests" (
+    "id" UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    "customer_id" UUID NOT NULL,
+    "status" VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'running', 'completed', 'failed', 'expired')),
+    "error" TEXT,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "finished_at" TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_dsar_requests_customer
+        FOREIGN KEY ("customer_id")
+        REFERENCES "customers"("id")
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_dsar_requests_finished_at" ON "dsar_requests" ("finished_at") WHERE status = 'completed';
@@ -0,0 +1,26 @@
+ALTER TABLE "customers" ADD COLUMN IF NOT EXISTS "erased_at" TIMESTAMP WITH TIME ZONE;
+
+-- certificates outlive the customer row on purpose, no foreign key
+CREATE TABLE IF NOT EXISTS "erasure_certificates" (
+    "id" UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    "customer_id" UUID NOT NULL UNIQUE,
+    "actor" VARCHAR(255) NOT NULL,
+    "reason" TEXT NOT NULL DEFAULT '',
+    "erased_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- transactional outbox, rows are written in the same transaction as the
+-- change they describe and relayed to the publisher afterwards
+CREATE TABLE IF NOT EXISTS "outbox_events" (
+    "id" UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    "type" VARCHAR(100) NOT NULL,
+    "aggregate_id" UUID NOT NULL,
+    "payload" JSONB NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "published_at" TIMESTAMP WITH TIME ZONE,
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "last_error" TEXT
+);
+
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_pending" ON "outbox_events" ("created_at") WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_published_at" ON "outbox_events" ("published_at") WHERE published_at IS NOT NULL;
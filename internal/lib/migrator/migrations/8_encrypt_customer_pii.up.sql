@@ -0,0 +1,55 @@
+-- data keys, stored wrapped by a master key that never reaches the database
+CREATE TABLE IF NOT EXISTS "encryption_keys" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "master_key_id" VARCHAR(100) NOT NULL,
+    "wrapped_key" BYTEA NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- encrypted columns keep NULL in the plaintext column, rows without
+-- data_key_id are plaintext and are encrypted by the rotation command
+ALTER TABLE "customers"
+    ALTER COLUMN "first_name" DROP NOT NULL,
+    ALTER COLUMN "last_name" DROP NOT NULL,
+    ALTER COLUMN "birthday" DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS "first_name_enc" BYTEA,
+    ADD COLUMN IF NOT EXISTS "last_name_enc" BYTEA,
+    ADD COLUMN IF NOT EXISTS "birthday_enc" BYTEA,
+    ADD COLUMN IF NOT EXISTS "first_name_bidx" BYTEA,
+    ADD COLUMN IF NOT EXISTS "last_name_bidx" BYTEA,
+    ADD COLUMN IF NOT EXISTS "birthday_bidx" BYTEA,
+    ADD COLUMN IF NOT EXISTS "data_key_id" BIGINT REFERENCES "encryption_keys"("id");
+
+CREATE INDEX IF NOT EXISTS "idx_customers_first_name_bidx" ON "customers" ("first_name_bidx");
+CREATE INDEX IF NOT EXISTS "idx_customers_last_name_bidx" ON "customers" ("last_name_bidx");
+CREATE INDEX IF NOT EXISTS "idx_customers_birthday_bidx" ON "customers" ("birthday_bidx");
+CREATE INDEX IF NOT EXISTS "idx_customers_data_key_id" ON "customers" ("data_key_id");
+CREATE INDEX IF NOT EXISTS "idx_customers_first_name_lower" ON "customers" (lower("first_name"));
+CREATE INDEX IF NOT EXISTS "idx_customers_last_name_lower" ON "customers" (lower("last_name"));
+CREATE INDEX IF NOT EXISTS "idx_customers_birthday" ON "customers" ("birthday");
+
+-- blind indexes are left out of history, re-encryption with the same
+-- values under a new key is not a change
+CREATE OR REPLACE FUNCTION record_customer_change() RETURNS TRIGGER AS $$
+DECLARE
+    old_row JSONB;
+    new_row JSONB := to_jsonb(NEW) - ARRAY['first_name_bidx', 'last_name_bidx', 'birthday_bidx'];
+BEGIN
+    IF current_setting('user_service.reencrypting', true) = 'on' THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO customer_changes (customer_id, operation, new_data)
+        VALUES (NEW.id, 'insert', new_row);
+        RETURN NULL;
+    END IF;
+
+    old_row := to_jsonb(OLD) - ARRAY['first_name_bidx', 'last_name_bidx', 'birthday_bidx'];
+    IF old_row IS DISTINCT FROM new_row THEN
+        INSERT INTO customer_changes (customer_id, operation, old_data, new_data)
+        VALUES (NEW.id, 'update', old_row, new_row);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,29 @@
+-- append-only, the latest record per customer and purpose is the current state
+CREATE TABLE IF NOT EXISTS "consents" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "customer_id" UUID NOT NULL,
+    "purpose" VARCHAR(50) NOT NULL,
+    "status" VARCHAR(20) NOT NULL CHECK (status IN ('granted', 'withdrawn')),
+    "source" VARCHAR(50) NOT NULL,
+    "legal_basis_version" VARCHAR(50) NOT NULL,
+    "recorded_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT fk_consents_customer
+        FOREIGN KEY ("customer_id")
+        REFERENCES "customers"("id")
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_consents_customer_purpose" ON "consents" ("customer_id", "purpose", "id" DESC);
+CREATE INDEX IF NOT EXISTS "idx_consents_purpose_customer" ON "consents" ("purpose", "customer_id", "id" DESC);
+
+-- rows go away only together with the customer
+CREATE OR REPLACE FUNCTION reject_consent_update() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'consents are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "consents_append_only" ON "consents";
+CREATE TRIGGER "consents_append_only"
+    BEFORE UPDATE ON "consents"
+    FOR EACH ROW EXECUTE FUNCTION reject_consent_update();
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"user-service/internal/config"
+)
+
+// Limit token bucket parameters: Rate tokens per second refill, Burst capacity.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // until next token, zero if allowed
+	ResetAfter time.Duration // until bucket is full again
+}
+
+// Store keeps bucket state.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limiter resolves per-route limits and takes tokens from store.
+type Limiter struct {
+	store    Store
+	fallback Limit
+	routes   map[string]Limit
+}
+
+// New creates limiter. Route keys are "METHOD /pattern", e.g. "POST /api/v1/customers".
+func New(store Store, cfg config.RateLimitConfig) *Limiter {
+	routes := make(map[string]Limit, len(cfg.Routes))
+	for route, l := range cfg.Routes {
+		routes[normalizeRoute(route)] = Limit{Rate: l.Rate, Burst: l.Burst}
+	}
+
+	return &Limiter{
+		store:    store,
+		fallback: Limit{Rate: cfg.Default.Rate, Burst: cfg.Default.Burst},
+		routes:   routes,
+	}
+}
+
+// Allow takes a token for client on route. Buckets are separate per
+// client and per route that has an override.
+func (l *Limiter) Allow(ctx context.Context, client, method, pattern string) (Result, error) {
+	const op = "ratelimit.Allow"
+
+	route := normalizeRoute(method + " " + pattern)
+	limit, ok := l.routes[route]
+	key := client
+	if ok {
+		key = client + "|" + route
+	} else {
+		limit = l.fallback
+	}
+
+	if limit.Rate <= 0 || limit.Burst <= 0 {
+		return Result{Allowed: true, Limit: limit.Burst, Remaining: limit.Burst}, nil
+	}
+
+	res, err := l.store.Take(ctx, key, limit)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+func normalizeRoute(route string) string {
+	method, path, _ := strings.Cut(strings.TrimSpace(route), " ")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return strings.ToUpper(method) + " " + path
+}
+
+// compute applies token bucket math shared by all stores.
+// tokens is the bucket level after refill.
+func compute(tokens float64, limit Limit) (Result, float64) {
+	res := Result{Limit: limit.Burst}
+
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	}
+
+	res.Remaining = int(tokens)
+	res.ResetAfter = time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second))
+
+	return res, tokens
+}
@@ -0,0 +1,172 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"user-service/internal/config"
+)
+
+func TestNormalizeRoute(t *testing.T) {
+	tests := []struct {
+		name  string
+		route string
+		want  string
+	}{
+		{"canonical", "POST /api/v1/customers", "POST /api/v1/customers"},
+		{"lower case method", "post /api/v1/customers", "POST /api/v1/customers"},
+		{"trailing slash", "GET /api/v1/customers/", "GET /api/v1/customers"},
+		{"root kept", "GET /", "GET /"},
+		{"spaces trimmed", "  GET /api/v1/customers/{id}  ", "GET /api/v1/customers/{id}"},
+		{"no path", "GET", "GET "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeRoute(tt.route); got != tt.want {
+				t.Fatalf("normalizeRoute(%q) = %q, want %q", tt.route, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 10}
+
+	tests := []struct {
+		name       string
+		tokens     float64
+		want       Result
+		wantTokens float64
+	}{
+		{
+			name:   "full bucket",
+			tokens: 10,
+			want: Result{
+				Allowed: true, Limit: 10, Remaining: 9,
+				ResetAfter: 500 * time.Millisecond,
+			},
+			wantTokens: 9,
+		},
+		{
+			name:   "last token",
+			tokens: 1,
+			want: Result{
+				Allowed: true, Limit: 10, Remaining: 0,
+				ResetAfter: 5 * time.Second,
+			},
+			wantTokens: 0,
+		},
+		{
+			name:   "fraction left is rounded down",
+			tokens: 2.5,
+			want: Result{
+				Allowed: true, Limit: 10, Remaining: 1,
+				ResetAfter: 4250 * time.Millisecond,
+			},
+			wantTokens: 1.5,
+		},
+		{
+			name:   "empty bucket",
+			tokens: 0,
+			want: Result{
+				Limit: 10, Remaining: 0,
+				RetryAfter: 500 * time.Millisecond, ResetAfter: 5 * time.Second,
+			},
+			wantTokens: 0,
+		},
+		{
+			name:   "partly refilled token",
+			tokens: 0.75,
+			want: Result{
+				Limit: 10, Remaining: 0,
+				RetryAfter: 125 * time.Millisecond, ResetAfter: 4625 * time.Millisecond,
+			},
+			wantTokens: 0.75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, tokens := compute(tt.tokens, limit)
+			if got != tt.want || tokens != tt.wantTokens {
+				t.Fatalf("compute(%v) = %+v, %v, want %+v, %v", tt.tokens, got, tokens, tt.want, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreRefill(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 2}
+
+	for i, want := range []bool{true, true, false} {
+		res, err := s.Take(ctx, "client", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed != want {
+			t.Fatalf("Take() #%d allowed = %v, want %v", i+1, res.Allowed, want)
+		}
+	}
+
+	// a second and a half later one token is back, the next one is half a second away
+	s.buckets["client"].last = s.buckets["client"].last.Add(-1500 * time.Millisecond)
+	res, _ := s.Take(ctx, "client", limit)
+	if !res.Allowed {
+		t.Fatal("Take() after refill is not allowed")
+	}
+	res, _ = s.Take(ctx, "client", limit)
+	if res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > 500*time.Millisecond {
+		t.Fatalf("Take() = %+v, want rejected with retry within 500ms", res)
+	}
+
+	// refill never goes above burst
+	s.buckets["client"].last = s.buckets["client"].last.Add(-time.Hour)
+	res, _ = s.Take(ctx, "client", limit)
+	if !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("Take() after idle = %+v, want allowed with 1 remaining", res)
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	l := New(NewMemoryStore(), config.RateLimitConfig{
+		Default: config.LimitConfig{Rate: 1, Burst: 1},
+		Routes: map[string]config.LimitConfig{
+			"post /api/v1/customers/": {Rate: 1, Burst: 2},
+			"GET /healthz":            {Rate: 0, Burst: 0},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		client  string
+		method  string
+		pattern string
+		want    bool
+	}{
+		{"default bucket", "a", "GET", "/api/v1/customers", true},
+		{"default bucket is shared by routes", "a", "GET", "/api/v1/customers/{id}", false},
+		{"other client", "b", "GET", "/api/v1/customers", true},
+		{"override has own bucket", "a", "POST", "/api/v1/customers", true},
+		{"override burst", "a", "POST", "/api/v1/customers", true},
+		{"override exhausted", "a", "POST", "/api/v1/customers", false},
+		{"zero limit is unlimited", "a", "GET", "/healthz", true},
+		{"zero limit again", "a", "GET", "/healthz", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := l.Allow(ctx, tt.client, tt.method, tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Allowed != tt.want {
+				t.Fatalf("Allow(%s, %s %s) = %v, want %v", tt.client, tt.method, tt.pattern, res.Allowed, tt.want)
+			}
+		})
+	}
+}
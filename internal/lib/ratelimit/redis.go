@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills and takes a token atomically. Returns {allowed, tokens left},
+// tokens as string because redis truncates lua numbers to integers.
+var takeScript = redis.NewScript(`
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", key, "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+  tokens = burst
+  ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000000 * rate)
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call("HSET", key, "tokens", tokens, "ts", now)
+redis.call("PEXPIRE", key, math.ceil(burst / rate * 1000) + 1000)
+
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore token buckets shared between service instances.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client, prefix: "ratelimit:"}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	const op = "ratelimit.RedisStore.Take"
+
+	now := time.Now().UnixMicro()
+	raw, err := takeScript.Run(ctx, s.client, []string{s.prefix + key}, limit.Rate, limit.Burst, now).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(raw) != 2 {
+		return Result{}, fmt.Errorf("%s: unexpected script result %v", op, raw)
+	}
+
+	allowed, _ := raw[0].(int64)
+	str, _ := raw[1].(string)
+	tokens, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: invalid tokens %q: %w", op, str, err)
+	}
+
+	// script already took the token, compute only derives headers
+	before := tokens
+	if allowed == 1 {
+		before = tokens + 1
+	}
+	res, _ := compute(before, limit)
+
+	return res, nil
+}
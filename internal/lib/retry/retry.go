@@ -0,0 +1,68 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"user-service/internal/config"
+)
+
+// Do calls fn until it succeeds, ctx is done or policy MaxElapsedTime is exceeded.
+// Delay between attempts grows exponentially and is randomized by Jitter.
+func Do(ctx context.Context, policy config.RetryConfig, log *slog.Logger, name string, fn func(ctx context.Context) error) error {
+	const op = "retry.Do"
+
+	log = log.With(slog.String("op", op), slog.String("target", name))
+
+	start := time.Now()
+	interval := policy.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			if attempt > 1 {
+				log.Info("connected after retries", slog.Int("attempt", attempt))
+			}
+			return nil
+		}
+
+		elapsed := time.Since(start)
+		if elapsed >= policy.MaxElapsedTime {
+			return fmt.Errorf("%s: giving up after %d attempts in %s: %w", name, attempt, elapsed.Round(time.Millisecond), err)
+		}
+
+		delay := withJitter(interval, policy.Jitter)
+		if remaining := policy.MaxElapsedTime - elapsed; delay > remaining {
+			delay = remaining
+		}
+
+		log.Warn("attempt failed, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", delay),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w (last error: %v)", name, ctx.Err(), err)
+		case <-time.After(delay):
+		}
+
+		interval = time.Duration(float64(interval) * policy.Multiplier)
+		if interval > policy.MaxInterval {
+			interval = policy.MaxInterval
+		}
+	}
+}
+
+// withJitter randomizes d by ±jitter fraction.
+func withJitter(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return d
+	}
+	delta := jitter * float64(d)
+	return time.Duration(float64(d) - delta + rand.Float64()*2*delta)
+}
@@ -0,0 +1,58 @@
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalid = errors.New("invalid signature")
+	ErrExpired = errors.New("link expired")
+)
+
+// Sign returns path with expires and HMAC signature query parameters,
+// the link is valid until expires without further authentication.
+func Sign(secret, path string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	q := url.Values{}
+	q.Set("expires", exp)
+	q.Set("signature", mac(secret, path, exp))
+	return path + "?" + q.Encode()
+}
+
+// Verify checks signature of path against query produced by Sign.
+func Verify(secret, path string, query url.Values, now time.Time) error {
+	exp := query.Get("expires")
+	sig, err := hex.DecodeString(query.Get("signature"))
+	if exp == "" || err != nil {
+		return ErrInvalid
+	}
+
+	want, _ := hex.DecodeString(mac(secret, path, exp))
+	if !hmac.Equal(sig, want) {
+		return ErrInvalid
+	}
+
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return ErrInvalid
+	}
+	if now.After(time.Unix(unix, 0)) {
+		return ErrExpired
+	}
+
+	return nil
+}
+
+func mac(secret, path, expires string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write([]byte(expires))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,78 @@
+package signedurl
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const secret = "test-secret-key-of-at-least-32-bytes"
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(time.Hour)
+
+	signed := Sign(secret, "/exports/1", expires)
+	path, raw, _ := strings.Cut(signed, "?")
+	valid, err := url.ParseQuery(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	with := func(key, value string) url.Values {
+		q := url.Values{}
+		for k, v := range valid {
+			q[k] = v
+		}
+		if value == "" {
+			q.Del(key)
+		} else {
+			q.Set(key, value)
+		}
+		return q
+	}
+	otherSig, _ := url.ParseQuery(strings.SplitN(Sign(secret, "/exports/2", expires), "?", 2)[1])
+
+	tests := []struct {
+		name    string
+		secret  string
+		path    string
+		query   url.Values
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", secret, path, valid, now, nil},
+		{"at expiry", secret, path, valid, expires, nil},
+		{"after expiry", secret, path, valid, expires.Add(time.Second), ErrExpired},
+		{"other path", secret, "/exports/2", valid, now, ErrInvalid},
+		{"other secret", "another-secret", path, valid, now, ErrInvalid},
+		{"extended expiry", secret, path, with("expires", "9999999999"), now, ErrInvalid},
+		{"signature of other path", secret, path, with("signature", otherSig.Get("signature")), now, ErrInvalid},
+		{"signature not hex", secret, path, with("signature", "zz"), now, ErrInvalid},
+		{"truncated signature", secret, path, with("signature", valid.Get("signature")[:10]), now, ErrInvalid},
+		{"missing signature", secret, path, with("signature", ""), now, ErrInvalid},
+		{"missing expires", secret, path, with("expires", ""), now, ErrInvalid},
+		{"empty query", secret, path, url.Values{}, now, ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.path, tt.query, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignKeepsPath(t *testing.T) {
+	signed := Sign(secret, "/exports/1", time.Unix(1700000000, 0))
+	if !strings.HasPrefix(signed, "/exports/1?") {
+		t.Fatalf("Sign() = %q, want path prefix", signed)
+	}
+	if !strings.Contains(signed, "expires=1700000000") {
+		t.Fatalf("Sign() = %q, want unix expires", signed)
+	}
+}
@@ -0,0 +1,145 @@
+package slog
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"user-service/internal/config"
+)
+
+const redacted = "[REDACTED]"
+
+// piiPattern matches values that look like PII in free text. Timestamps,
+// UUIDs and IPv4 addresses are matched first and kept, so ids, times and
+// hosts in error messages are not mistaken for dates or phone numbers.
+var piiPattern = regexp.MustCompile(
+	`(?P<keep>[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}` +
+		`|\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?` +
+		`|\b\d{1,3}(?:\.\d{1,3}){3}\b)` +
+		`|[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}` +
+		`|\b\d{4}-\d{2}-\d{2}\b` +
+		`|\+?\d[\d ().-]{7,}\d`,
+)
+
+// RedactPolicy decides how attribute values are redacted.
+type RedactPolicy struct {
+	mask   map[string]bool
+	hash   map[string]bool
+	scrub  map[string]bool
+	secret []byte
+	// pairs matches key=value, key: value and Postgres "(key)=(value)"
+	// for masked keys
+	pairs *regexp.Regexp
+}
+
+// NewRedactPolicy creates policy from cfg, hashed values are keyed with
+// secret so short values like birthdays cannot be found by brute force.
+func NewRedactPolicy(cfg config.LogConfig, secret string) *RedactPolicy {
+	p := &RedactPolicy{
+		mask:   keySet(cfg.Mask),
+		hash:   keySet(cfg.Hash),
+		scrub:  keySet(cfg.Scrub),
+		secret: []byte(secret),
+	}
+
+	if len(cfg.Mask) > 0 {
+		keys := make([]string, len(cfg.Mask))
+		for i, k := range cfg.Mask {
+			keys[i] = regexp.QuoteMeta(k)
+		}
+		p.pairs = regexp.MustCompile(`(?i)\b(` + strings.Join(keys, "|") + `)(\s*[:=]\s*|\)=\()("[^"]*"|'[^']*'|[^\s,;)]+)`)
+	}
+
+	return p
+}
+
+// Unredacted reports whether redaction is turned off, which needs both
+// the config setting and the command line flag.
+func Unredacted(cfg config.LogConfig) bool {
+	return cfg.DebugUnredacted && cfg.UnredactedFlag
+}
+
+// Scrub removes PII patterns and values of masked keys from s.
+func (p *RedactPolicy) Scrub(s string) string {
+	if p.pairs != nil {
+		s = p.pairs.ReplaceAllString(s, "${1}${2}"+redacted)
+	}
+	keep := piiPattern.SubexpIndex("keep")
+	return piiPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if sub := piiPattern.FindStringSubmatch(m); sub != nil && sub[keep] == m {
+			return m
+		}
+		return redacted
+	})
+}
+
+func (p *RedactPolicy) redact(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	key := strings.ToLower(a.Key)
+
+	switch {
+	case a.Value.Kind() == slog.KindGroup:
+		attrs := a.Value.Group()
+		res := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			res[i] = p.redact(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(res...)}
+	case p.mask[key]:
+		return slog.String(a.Key, redacted)
+	case p.hash[key]:
+		h := hmac.New(sha256.New, p.secret)
+		h.Write([]byte(a.Value.String()))
+		return slog.String(a.Key, "h:"+hex.EncodeToString(h.Sum(nil)[:8]))
+	case p.scrub[key]:
+		return slog.String(a.Key, p.Scrub(a.Value.String()))
+	}
+	return a
+}
+
+// RedactHandler removes PII from records before passing them on.
+type RedactHandler struct {
+	slog.Handler
+	policy *RedactPolicy
+}
+
+// NewRedactHandler create new RedactHandler.
+func NewRedactHandler(handler slog.Handler, policy *RedactPolicy) *RedactHandler {
+	return &RedactHandler{Handler: handler, policy: policy}
+}
+
+// Handle processing log record.
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	res := slog.NewRecord(r.Time, r.Level, h.policy.Scrub(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		res.AddAttrs(h.policy.redact(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, res)
+}
+
+// WithAttrs new handler with additional attributes
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	res := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		res[i] = h.policy.redact(a)
+	}
+	return &RedactHandler{Handler: h.Handler.WithAttrs(res), policy: h.policy}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{Handler: h.Handler.WithGroup(name), policy: h.policy}
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = true
+	}
+	return set
+}
@@ -0,0 +1,141 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"user-service/internal/config"
+)
+
+func newTestPolicy() *RedactPolicy {
+	return NewRedactPolicy(config.LogConfig{
+		Mask:  []string{"first_name", "birthday"},
+		Hash:  []string{"customer_id"},
+		Scrub: []string{"error"},
+	}, "test-secret")
+}
+
+func TestScrub(t *testing.T) {
+	p := newTestPolicy()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "failed to create customer", "failed to create customer"},
+		{"email", "duplicate email alice@example.com", "duplicate email [REDACTED]"},
+		{"date", "born 1990-05-15 in Berlin", "born [REDACTED] in Berlin"},
+		{"phone", "call +49 (30) 1234-5678 now", "call [REDACTED] now"},
+		{"uuid kept", "customer 1b4e28ba-2fa1-11d2-883f-0016d3cca427 not found", "customer 1b4e28ba-2fa1-11d2-883f-0016d3cca427 not found"},
+		{"timestamp kept", "expired at 2024-05-15T10:20:30Z", "expired at 2024-05-15T10:20:30Z"},
+		{"timestamp with offset kept", "expired at 2024-05-15 10:20:30.123+02:00", "expired at 2024-05-15 10:20:30.123+02:00"},
+		{"ipv4 kept", "dial tcp 10.0.12.7:5432: connection refused", "dial tcp 10.0.12.7:5432: connection refused"},
+		{"ipv4 next to email", "10.0.0.1 rejected bob@example.org", "10.0.0.1 rejected [REDACTED]"},
+		{"dotted phone", "phone 555.123.4567", "phone [REDACTED]"},
+		{"long group not ipv4", "ref 1234.56.78.90", "ref [REDACTED]"},
+		{"masked key equals", "first_name=Alice last", "first_name=[REDACTED] last"},
+		{"masked key colon", "First_Name: Alice", "First_Name: [REDACTED]"},
+		{"masked key quoted", `first_name="Alice Smith", ok`, `first_name=[REDACTED], ok`},
+		{"postgres detail", "Key (first_name)=(Alice) already exists", "Key (first_name)=([REDACTED]) already exists"},
+		{"other key kept", "gender=female", "gender=female"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Scrub(tt.in); got != tt.want {
+				t.Fatalf("Scrub(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	p := newTestPolicy()
+	hashed := p.redact(slog.String("customer_id", "42")).Value.String()
+
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{"masked", slog.String("first_name", "Alice"), redacted},
+		{"masked ignores case", slog.String("Birthday", "1990-05-15"), redacted},
+		{"masked non string", slog.Int("birthday", 19900515), redacted},
+		{"hashed is stable", slog.String("customer_id", "42"), hashed},
+		{"scrubbed", slog.String("error", "no row for a@b.io"), "no row for " + redacted},
+		{"unlisted kept", slog.String("op", "service.customer.Create"), "service.customer.Create"},
+		{"unlisted not scrubbed", slog.String("email_domain", "a@b.io"), "a@b.io"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.redact(tt.attr).Value.String(); got != tt.want {
+				t.Fatalf("redact(%v) = %q, want %q", tt.attr, got, tt.want)
+			}
+		})
+	}
+
+	if len(hashed) != len("h:")+16 || hashed[:2] != "h:" {
+		t.Fatalf("redact() hash = %q, want h: and 16 hex digits", hashed)
+	}
+	other := NewRedactPolicy(config.LogConfig{Hash: []string{"customer_id"}}, "another-secret")
+	if got := other.redact(slog.String("customer_id", "42")).Value.String(); got == hashed {
+		t.Fatal("redact() hash does not depend on secret")
+	}
+}
+
+func TestRedactHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewRedactHandler(slog.NewJSONHandler(&buf, nil), newTestPolicy())
+	log := slog.New(h).With(slog.String("first_name", "Alice")).WithGroup("req")
+
+	log.InfoContext(context.Background(), "mail to alice@example.com",
+		slog.Group("customer", slog.String("birthday", "1990-05-15")),
+	)
+
+	var rec struct {
+		Msg       string `json:"msg"`
+		FirstName string `json:"first_name"`
+		Req       struct {
+			Customer struct {
+				Birthday string `json:"birthday"`
+			} `json:"customer"`
+		} `json:"req"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Msg != "mail to "+redacted {
+		t.Fatalf("msg = %q, want scrubbed", rec.Msg)
+	}
+	if rec.FirstName != redacted {
+		t.Fatalf("first_name = %q, want %q", rec.FirstName, redacted)
+	}
+	if rec.Req.Customer.Birthday != redacted {
+		t.Fatalf("req.customer.birthday = %q, want %q", rec.Req.Customer.Birthday, redacted)
+	}
+}
+
+func TestUnredacted(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.LogConfig
+		want bool
+	}{
+		{"default", config.LogConfig{}, false},
+		{"config only", config.LogConfig{DebugUnredacted: true}, false},
+		{"flag only", config.LogConfig{UnredactedFlag: true}, false},
+		{"both", config.LogConfig{DebugUnredacted: true, UnredactedFlag: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unredacted(tt.cfg); got != tt.want {
+				t.Fatalf("Unredacted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Package timezone validates and normalizes IANA time zone names.
+package timezone
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	// fallback when the host has no zoneinfo, names must load everywhere
+	_ "time/tzdata"
+)
+
+// maxNameLength size of customers.timezone column.
+const maxNameLength = 50
+
+var ErrUnknown = errors.New("unknown time zone")
+
+var locations sync.Map
+
+// canonical maps lower cased zone and alias names to canonical names.
+var canonical = func() map[string]string {
+	m := make(map[string]string, len(zones)+len(aliases))
+	for _, name := range zones {
+		m[strings.ToLower(name)] = name
+	}
+	for alias, name := range aliases {
+		m[strings.ToLower(alias)] = name
+	}
+	return m
+}()
+
+// Normalize returns canonical name of the zone: case is fixed, deprecated
+// links and legacy zones (US/Eastern, Europe/Kiev, EST5EDT) are replaced
+// by the zone they point to. Names unknown to the table but present in
+// the IANA database are accepted as is, so newer zoneinfo keeps working.
+func Normalize(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "Local" {
+		return "", ErrUnknown
+	}
+	if c, ok := canonical[strings.ToLower(name)]; ok {
+		name = c
+	} else if len(name) > maxNameLength || strings.HasPrefix(name, "posix/") || strings.HasPrefix(name, "right/") {
+		// posix/ and right/ are alternative zoneinfo trees, not zone names
+		return "", ErrUnknown
+	}
+	if _, err := Load(name); err != nil {
+		return "", ErrUnknown
+	}
+	return name, nil
+}
+
+// Load is time.LoadLocation with a cache, zoneinfo is read once per zone.
+// Empty name is an error instead of UTC.
+func Load(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	if name == "" || name == "Local" {
+		return nil, ErrUnknown
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// LoadOrUTC is Load that falls back to UTC, for zone names read from
+// storage that were not validated when written.
+func LoadOrUTC(name string) *time.Location {
+	loc, err := Load(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Zones returns canonical zone names sorted by name.
+func Zones() []string {
+	return append([]string(nil), zones...)
+}
+
+// Offset formats offset of t from UTC as ±HH:MM.
+func Offset(t time.Time) string {
+	return t.Format("-07:00")
+}
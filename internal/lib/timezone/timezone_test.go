@@ -0,0 +1,114 @@
+package timezone
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"canonical", "Europe/Berlin", "Europe/Berlin", false},
+		{"case fixed", "europe/berlin", "Europe/Berlin", false},
+		{"spaces trimmed", " Asia/Tokyo ", "Asia/Tokyo", false},
+		{"deprecated link", "Europe/Kiev", "Europe/Kyiv", false},
+		{"legacy us zone", "US/Eastern", "America/New_York", false},
+		{"legacy rule zone", "EST5EDT", "America/New_York", false},
+		{"utc alias", "Etc/UTC", "UTC", false},
+		{"fixed offset", "Etc/GMT-14", "Etc/GMT-14", false},
+		{"empty", "", "", true},
+		{"local", "Local", "", true},
+		{"unknown", "Mars/Olympus_Mons", "", true},
+		{"posix tree", "posix/Europe/Berlin", "", true},
+		{"right tree", "right/Europe/Berlin", "", true},
+		{"offset is not a zone", "+03:00", "", true},
+		{"path traversal", "../../etc/passwd", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnknown) {
+				t.Fatalf("Normalize(%q) error = %v, want ErrUnknown", tt.in, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZonesLoad(t *testing.T) {
+	zs := Zones()
+	if !slices.IsSorted(zs) {
+		t.Fatal("Zones() is not sorted")
+	}
+	for _, name := range zs {
+		if _, err := Load(name); err != nil {
+			t.Errorf("Load(%q) error = %v", name, err)
+		}
+	}
+	for alias, name := range aliases {
+		if _, err := Load(name); err != nil {
+			t.Errorf("alias %q points to %q: %v", alias, name, err)
+		}
+	}
+}
+
+func TestOffset(t *testing.T) {
+	berlin, err := Load("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kolkata, err := Load("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{"utc", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "+00:00"},
+		{"winter time", time.Date(2024, 1, 1, 0, 0, 0, 0, berlin), "+01:00"},
+		{"summer time", time.Date(2024, 7, 1, 0, 0, 0, 0, berlin), "+02:00"},
+		{"half hour", time.Date(2024, 1, 1, 0, 0, 0, 0, kolkata), "+05:30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Offset(tt.t); got != tt.want {
+				t.Fatalf("Offset() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOrUTC(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"valid", "Europe/Berlin", "Europe/Berlin"},
+		{"empty", "", "UTC"},
+		{"local", "Local", "UTC"},
+		{"unknown", "Mars/Olympus_Mons", "UTC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LoadOrUTC(tt.in).String(); got != tt.want {
+				t.Fatalf("LoadOrUTC(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
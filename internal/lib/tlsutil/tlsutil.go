@@ -0,0 +1,178 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"user-service/internal/config"
+)
+
+// Reloader keeps server certificate and client CA pool in memory and
+// reloads them when files change on disk.
+type Reloader struct {
+	cfg config.TLSConfig
+	log *slog.Logger
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// NewReloader loads certificate files for the first time.
+func NewReloader(cfg config.TLSConfig, log *slog.Logger) (*Reloader, error) {
+	const op = "tlsutil.NewReloader"
+
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("%s: cert_file and key_file are required", op)
+	}
+
+	r := &Reloader{cfg: cfg, log: log}
+	if err := r.load(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// ServerConfig returns tls.Config with modern defaults (TLS 1.2+, AEAD ciphers,
+// HTTP/2). Certificates are resolved on every handshake, so reloads apply
+// to new connections without restart.
+func (r *Reloader) ServerConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		NextProtos:       []string{"h2", "http/1.1"},
+	}
+
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		c := base.Clone()
+		c.GetConfigForClient = nil
+		cert := r.cert
+		c.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return cert, nil
+		}
+		if r.clientCA != nil {
+			// probes connect without certificate, the API requires one
+			// in middleware
+			c.ClientAuth = tls.VerifyClientCertIfGiven
+			c.ClientCAs = r.clientCA
+		}
+		return c, nil
+	}
+
+	return base
+}
+
+// Run polls certificate files every ReloadInterval until ctx is done.
+func (r *Reloader) Run(ctx context.Context) {
+	const op = "tlsutil.Reloader.Run"
+
+	if r.cfg.ReloadInterval <= 0 {
+		return
+	}
+
+	log := r.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.changed()
+			if err != nil {
+				log.Warn("failed to stat certificate files", slog.String("error", err.Error()))
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if err := r.load(); err != nil {
+				log.Error("failed to reload certificates, keeping previous", slog.String("error", err.Error()))
+				continue
+			}
+			log.Info("certificates reloaded")
+		}
+	}
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+func (r *Reloader) changed() (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, f := range r.files() {
+		st, err := os.Stat(f)
+		if err != nil {
+			return false, err
+		}
+		if !st.ModTime().Equal(r.modTimes[f]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *Reloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, f := range r.files() {
+		st, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		modTimes[f] = st.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client ca: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("client ca file contains no certificates")
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCA = pool
+	r.modTimes = modTimes
+
+	return nil
+}
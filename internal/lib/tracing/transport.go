@@ -0,0 +1,23 @@
+package tracing
+
+import (
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewTransport wraps base, http.DefaultTransport if nil, so every outgoing
+// request gets a client span and W3C traceparent of the caller's context.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}
+
+// NewHTTPClient client for outgoing calls (webhooks, catalog, etc.),
+// publishers and notifiers that talk HTTP are built with it.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: NewTransport(nil), Timeout: timeout}
+}
@@ -0,0 +1,223 @@
+package attributes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/jsonschema"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+)
+
+type SchemaRepository interface {
+	List(ctx context.Context) ([]models.AttributeSchema, error)
+	Get(ctx context.Context, namespace string) (*models.AttributeSchema, error)
+	Save(ctx context.Context, namespace string, schema []byte) (*models.AttributeSchema, error)
+}
+
+type compiled struct {
+	version int
+	schema  *jsonschema.Schema
+}
+
+type Service struct {
+	log  *slog.Logger
+	repo SchemaRepository
+
+	mu sync.Mutex
+	// compiled schemas by namespace, recompiled when version changes
+	compiled map[string]compiled
+}
+
+func New(log *slog.Logger, repo SchemaRepository) *Service {
+	return &Service{
+		log:      log,
+		repo:     repo,
+		compiled: make(map[string]compiled),
+	}
+}
+
+// RegisterSchema registers JSON Schema for namespace or replaces it.
+// Stored values are not revalidated, they are checked against the new
+// schema when the namespace is written next time.
+func (s *Service) RegisterSchema(ctx context.Context, namespace string, schema []byte) (*models.AttributeSchema, error) {
+	const op = "service.attributes.RegisterSchema"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := dto.ValidateNamespace(namespace); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+	if _, err := jsonschema.Compile(schema); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+
+	saved, err := s.repo.Save(ctx, namespace, schema)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to save schema", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "attribute schema registered",
+		slog.String("namespace", namespace),
+		slog.Int("version", saved.Version),
+	)
+
+	return saved, nil
+}
+
+func (s *Service) GetSchema(ctx context.Context, namespace string) (*models.AttributeSchema, error) {
+	const op = "service.attributes.GetSchema"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	schema, err := s.repo.Get(ctx, namespace)
+	if err != nil {
+		if !errors.Is(err, storage.ErrAttributeSchemaNotFound) {
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return schema, nil
+}
+
+func (s *Service) ListSchemas(ctx context.Context) ([]models.AttributeSchema, error) {
+	const op = "service.attributes.ListSchemas"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	schemas, err := s.repo.List(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return schemas, nil
+}
+
+// Registry returns current schemas of all namespaces. Schemas are
+// loaded on every call so registration takes effect on every instance
+// at once, compiled ones are reused while their version stays the same.
+func (s *Service) Registry(ctx context.Context) (*Registry, error) {
+	const op = "service.attributes.Registry"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	schemas, err := s.repo.List(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reg := &Registry{schemas: make(map[string]*jsonschema.Schema, len(schemas))}
+	for _, sc := range schemas {
+		c, ok := s.compiled[sc.Namespace]
+		if !ok || c.version != sc.Version {
+			schema, err := jsonschema.Compile(sc.Schema)
+			if err != nil {
+				tracing.RecordError(span, err)
+				return nil, fmt.Errorf("%s: namespace %s: %w", op, sc.Namespace, err)
+			}
+			c = compiled{version: sc.Version, schema: schema}
+			s.compiled[sc.Namespace] = c
+		}
+		reg.schemas[sc.Namespace] = c.schema
+	}
+
+	return reg, nil
+}
+
+// Registry schemas by namespace at some point in time.
+type Registry struct {
+	schemas map[string]*jsonschema.Schema
+}
+
+// Validate checks every namespace of attrs is registered and its value
+// matches the schema. Null values are skipped, they remove the namespace.
+func (r *Registry) Validate(attrs map[string]json.RawMessage) error {
+	namespaces := make([]string, 0, len(attrs))
+	for namespace := range attrs {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		value := attrs[namespace]
+		if dto.IsNull(value) {
+			continue
+		}
+		schema, ok := r.schemas[namespace]
+		if !ok {
+			return fmt.Errorf("attribute namespace %s is not registered", namespace)
+		}
+		if err := schema.ValidateJSON(value); err != nil {
+			var verr *jsonschema.ValidationError
+			if errors.As(err, &verr) {
+				return fmt.Errorf("attributes.%s%s: %s", namespace, strings.ReplaceAll(verr.Path, "/", "."), verr.Message)
+			}
+			return fmt.Errorf("attributes.%s: %w", namespace, err)
+		}
+	}
+	return nil
+}
+
+// ResolveFilter converts filter value from query string to the type
+// the schema declares at the filter path, so attr.loyalty.level=3
+// matches number 3 and not string "3". Values at paths without declared
+// type stay strings.
+func (r *Registry) ResolveFilter(f *dto.AttributeFilter) error {
+	name := "attr." + strings.Join(f.Path, ".")
+
+	schema, ok := r.schemas[f.Path[0]]
+	if !ok {
+		return fmt.Errorf("%s: attribute namespace %s is not registered", name, f.Path[0])
+	}
+
+	raw, _ := f.Value.(string)
+	types := schema.Lookup(f.Path[1:]).Types()
+	if len(types) == 0 {
+		f.Value = raw
+		return nil
+	}
+
+	for _, t := range types {
+		switch t {
+		case "string":
+			f.Value = raw
+			return nil
+		case "number", "integer":
+			if n, err := strconv.ParseFloat(raw, 64); err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) {
+				f.Value = n
+				return nil
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(raw); err == nil {
+				f.Value = b
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s must be %s", name, strings.Join(types, " or "))
+}
@@ -0,0 +1,127 @@
+package consent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"user-service/internal/config"
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type ConsentRepository interface {
+	Record(ctx context.Context, customerID uuid.UUID, records []models.Consent) ([]models.Consent, error)
+	Current(ctx context.Context, customerID uuid.UUID) ([]models.Consent, error)
+	History(ctx context.Context, customerID uuid.UUID) ([]models.Consent, error)
+	ListGranted(ctx context.Context, purpose string, after uuid.UUID, limit int) ([]models.Consent, error)
+}
+
+type Service struct {
+	log      *slog.Logger
+	repo     ConsentRepository
+	purposes map[string]bool
+}
+
+// New creates service accepting consents for cfg.Purposes only.
+func New(log *slog.Logger, repo ConsentRepository, cfg config.ConsentsConfig) *Service {
+	purposes := make(map[string]bool, len(cfg.Purposes))
+	for _, p := range cfg.Purposes {
+		purposes[p] = true
+	}
+	return &Service{
+		log:      log,
+		repo:     repo,
+		purposes: purposes,
+	}
+}
+
+// UpdateConsents records consent decisions and returns the current consents.
+func (s *Service) UpdateConsents(ctx context.Context, customerID uuid.UUID, req *dto.UpdateConsentsRequest) ([]models.Consent, error) {
+	const op = "service.consent.UpdateConsents"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+
+	records := make([]models.Consent, len(req.Consents))
+	for i, c := range req.Consents {
+		if !s.purposes[c.Purpose] {
+			return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: fmt.Errorf("consents[%d]: unknown purpose %s", i, c.Purpose)})
+		}
+		records[i] = models.Consent{
+			Purpose:           c.Purpose,
+			Status:            c.Status,
+			Source:            c.Source,
+			LegalBasisVersion: c.LegalBasisVersion,
+		}
+	}
+
+	consents, err := s.repo.Record(ctx, customerID, records)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) && !errors.Is(err, storage.ErrCustomerErased) {
+			log.ErrorContext(ctx, "failed to record consents", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "consents updated", slog.String("customer_id", customerID.String()))
+
+	return consents, nil
+}
+
+// GetConsents returns the current consents, or every record when history is set.
+func (s *Service) GetConsents(ctx context.Context, customerID uuid.UUID, history bool) ([]models.Consent, error) {
+	const op = "service.consent.GetConsents"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	get := s.repo.Current
+	if history {
+		get = s.repo.History
+	}
+
+	consents, err := get(ctx, customerID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) {
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return consents, nil
+}
+
+// ListConsenting returns a page of customers currently consenting to
+// purpose, ordered by customer id, starting after the given id.
+func (s *Service) ListConsenting(ctx context.Context, purpose string, after uuid.UUID, limit int) ([]models.Consent, error) {
+	const op = "service.consent.ListConsenting"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if !s.purposes[purpose] {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: fmt.Errorf("unknown purpose %s", purpose)})
+	}
+
+	consents, err := s.repo.ListGranted(ctx, purpose, after, limit)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return consents, nil
+}
@@ -0,0 +1,278 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/service"
+	attributesService "user-service/internal/service/attributes"
+	"user-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// batch item error codes
+const (
+	batchCodeValidation = "validation_error"
+	batchCodeNotFound   = "not_found"
+	batchCodeErased     = "erased"
+	batchCodeDuplicate  = "duplicate_id"
+	batchCodeStorage    = "storage_error"
+	batchCodeAborted    = "aborted"
+)
+
+// pending validated batch item waiting to be written
+type pending struct {
+	index    int
+	op       string
+	customer models.Customer
+}
+
+// BatchCustomers validates every operation and writes valid ones with one
+// batched insert and one batched update. In atomic mode any failure
+// leaves the database untouched and every item is reported as failed.
+func (s *Service) BatchCustomers(ctx context.Context, req *dto.BatchCustomersRequest) (*dto.BatchCustomersResponse, error) {
+	const op = "service.customer.BatchCustomers"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := req.Validate(); err != nil {
+		log.WarnContext(ctx, "validation failed", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+
+	reg, err := s.batchRegistry(ctx, req)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to load attribute schemas", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	genders, err := s.genders.Allowed(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to load genders", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	results := make([]dto.BatchItemResult, len(req.Operations))
+	items := make([]pending, 0, len(req.Operations))
+	updateReqs := make(map[uuid.UUID]int) // id -> operation index
+
+	for i := range req.Operations {
+		o := &req.Operations[i]
+		results[i] = dto.BatchItemResult{Index: i}
+
+		if err := o.Validate(); err != nil {
+			results[i].Fail(batchCodeValidation, err.Error())
+			continue
+		}
+		if err := reg.Validate(o.Attributes()); err != nil {
+			results[i].Fail(batchCodeValidation, err.Error())
+			continue
+		}
+
+		switch o.Op {
+		case dto.BatchOpCreate:
+			if err := genders.Check(o.Create.Gender); err != nil {
+				results[i].Fail(batchCodeValidation, err.Error())
+				continue
+			}
+			customer, err := NewCustomer(o.Create)
+			if err != nil {
+				results[i].Fail(batchCodeValidation, err.Error())
+				continue
+			}
+			items = append(items, pending{index: i, op: o.Op, customer: *customer})
+		case dto.BatchOpUpdate:
+			id, err := uuid.Parse(o.ID)
+			if err != nil {
+				results[i].Fail(batchCodeValidation, "invalid id")
+				continue
+			}
+			if _, dup := updateReqs[id]; dup {
+				results[i].Fail(batchCodeDuplicate, "customer is updated more than once in batch")
+				continue
+			}
+			updateReqs[id] = i
+			items = append(items, pending{index: i, op: o.Op, customer: models.Customer{ID: id}})
+		}
+	}
+
+	if len(updateReqs) > 0 {
+		ids := make([]uuid.UUID, 0, len(updateReqs))
+		for id := range updateReqs {
+			ids = append(ids, id)
+		}
+		existing, err := s.repo.GetByIDs(ctx, ids)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get customers", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		byID := make(map[uuid.UUID]models.Customer, len(existing))
+		for _, c := range existing {
+			byID[c.ID] = c
+		}
+
+		kept := items[:0]
+		for _, it := range items {
+			if it.op == dto.BatchOpUpdate {
+				c, ok := byID[it.customer.ID]
+				if !ok {
+					results[it.index].Fail(batchCodeNotFound, "customer not found")
+					continue
+				}
+				if c.ErasedAt != nil {
+					results[it.index].Fail(batchCodeErased, "customer is erased")
+					continue
+				}
+				update := req.Operations[it.index].Update
+				if update.Gender != nil && *update.Gender != c.Gender {
+					if err := genders.Check(*update.Gender); err != nil {
+						results[it.index].Fail(batchCodeValidation, err.Error())
+						continue
+					}
+				}
+				if err := applyUpdate(&c, update); err != nil {
+					results[it.index].Fail(batchCodeValidation, err.Error())
+					continue
+				}
+				it.customer = c
+			}
+			kept = append(kept, it)
+		}
+		items = kept
+	}
+
+	if req.Atomic && len(items) != len(req.Operations) {
+		abort(results)
+		log.InfoContext(ctx, "atomic batch rejected", slog.Int("operations", len(req.Operations)))
+		return summarize(req.Atomic, results), nil
+	}
+
+	var creates, updates []models.Customer
+	for _, it := range items {
+		if it.op == dto.BatchOpCreate {
+			creates = append(creates, it.customer)
+		} else {
+			updates = append(updates, it.customer)
+		}
+	}
+
+	err = s.repo.WriteBatch(ctx, creates, updates)
+	switch {
+	case err == nil:
+		for _, it := range items {
+			results[it.index].Succeed(it.op, it.customer.ID.String())
+		}
+	case req.Atomic:
+		log.ErrorContext(ctx, "failed to write batch", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	default:
+		// one bad row fails the whole statement, find it by writing one by one
+		log.WarnContext(ctx, "batched write failed, falling back to single writes", slog.String("error", err.Error()))
+		for _, it := range items {
+			if err := s.writeOne(ctx, it); err != nil {
+				code := batchCodeStorage
+				if errors.Is(err, storage.ErrUserNotFound) {
+					code = batchCodeNotFound
+				}
+				results[it.index].Fail(code, "failed to write customer")
+				continue
+			}
+			results[it.index].Succeed(it.op, it.customer.ID.String())
+		}
+	}
+
+	resp := summarize(req.Atomic, results)
+	for _, r := range results {
+		switch r.Status {
+		case dto.BatchStatusCreated:
+			metrics.CustomersCreated.Inc()
+		case dto.BatchStatusUpdated:
+			metrics.CustomersUpdated.Inc()
+		}
+	}
+
+	log.InfoContext(ctx, "batch processed",
+		slog.Int("succeeded", resp.Succeeded),
+		slog.Int("failed", resp.Failed),
+	)
+
+	return resp, nil
+}
+
+func (s *Service) writeOne(ctx context.Context, it pending) error {
+	if it.op == dto.BatchOpCreate {
+		return s.repo.Create(ctx, &it.customer)
+	}
+	return s.repo.Update(ctx, it.customer.ID, &it.customer)
+}
+
+// NewCustomer builds customer from validated create request.
+func NewCustomer(req *dto.CreateCustomerRequest) (*models.Customer, error) {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user_id: %w", err)
+	}
+
+	birthday, err := req.ParseBirthday()
+	if err != nil {
+		return nil, fmt.Errorf("invalid birthday: %w", err)
+	}
+
+	return &models.Customer{
+		ID:         uuid.New(),
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+		Gender:     req.Gender,
+		Timezone:   req.Timezone,
+		Birthday:   birthday,
+		UserID:     userID,
+		Attributes: models.Attributes(req.Attributes),
+	}, nil
+}
+
+// batchRegistry loads attribute schemas once for the whole batch, an
+// empty registry is returned when no operation writes attributes.
+func (s *Service) batchRegistry(ctx context.Context, req *dto.BatchCustomersRequest) (*attributesService.Registry, error) {
+	for i := range req.Operations {
+		if len(req.Operations[i].Attributes()) > 0 {
+			return s.attributes.Registry(ctx)
+		}
+	}
+	return &attributesService.Registry{}, nil
+}
+
+// abort marks every not yet failed item as aborted.
+func abort(results []dto.BatchItemResult) {
+	for i := range results {
+		if results[i].Status != dto.BatchStatusError {
+			results[i].Fail(batchCodeAborted, "atomic batch aborted because another operation failed")
+		}
+	}
+}
+
+func summarize(atomic bool, results []dto.BatchItemResult) *dto.BatchCustomersResponse {
+	resp := &dto.BatchCustomersResponse{Atomic: atomic, Results: results}
+	for _, r := range results {
+		if r.Status == dto.BatchStatusError {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+	return resp
+}
@@ -0,0 +1,62 @@
+package customer
+
+import (
+	"testing"
+
+	"user-service/internal/domain/dto"
+)
+
+func TestSummarize(t *testing.T) {
+	ok := func(i int) dto.BatchItemResult {
+		r := dto.BatchItemResult{Index: i}
+		r.Succeed(dto.BatchOpCreate, "id")
+		return r
+	}
+	failed := func(i int) dto.BatchItemResult {
+		r := dto.BatchItemResult{Index: i}
+		r.Fail(batchCodeValidation, "invalid gender")
+		return r
+	}
+
+	tests := []struct {
+		name          string
+		atomic        bool
+		aborted       bool
+		results       []dto.BatchItemResult
+		wantSucceeded int
+		wantFailed    int
+	}{
+		{"all succeeded", false, false, []dto.BatchItemResult{ok(0), ok(1)}, 2, 0},
+		{"partial", false, false, []dto.BatchItemResult{ok(0), failed(1), ok(2)}, 2, 1},
+		{"atomic succeeded", true, false, []dto.BatchItemResult{ok(0), ok(1)}, 2, 0},
+		{"atomic aborted", true, true, []dto.BatchItemResult{ok(0), failed(1), ok(2)}, 0, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.aborted {
+				abort(tt.results)
+			}
+			got := summarize(tt.atomic, tt.results)
+			if got.Atomic != tt.atomic || got.Succeeded != tt.wantSucceeded || got.Failed != tt.wantFailed {
+				t.Fatalf("summarize() = atomic %v, %d succeeded, %d failed, want %v, %d, %d",
+					got.Atomic, got.Succeeded, got.Failed, tt.atomic, tt.wantSucceeded, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestAbort(t *testing.T) {
+	results := make([]dto.BatchItemResult, 2)
+	results[0].Fail(batchCodeNotFound, "customer not found")
+	results[1].Succeed(dto.BatchOpUpdate, "id")
+
+	abort(results)
+
+	if results[0].Error.Code != batchCodeNotFound {
+		t.Fatalf("abort() code = %q, want original failure %q", results[0].Error.Code, batchCodeNotFound)
+	}
+	if results[1].Status != dto.BatchStatusError || results[1].Error.Code != batchCodeAborted {
+		t.Fatalf("abort() = %+v, want %q error", results[1], batchCodeAborted)
+	}
+}
@@ -0,0 +1,87 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/lib/birthday"
+	"user-service/internal/lib/timezone"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// BirthdayCustomers returns up to limit customers with id greater than
+// after whose birthday in their own timezone overlaps the filter window.
+// Candidates are selected by month and day and checked here, a short
+// page means there are no more matches.
+func (s *Service) BirthdayCustomers(ctx context.Context, filter dto.BirthdaysFilter, after uuid.UUID, limit int) ([]dto.CustomerBirthday, error) {
+	const op = "service.customer.BirthdayCustomers"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+
+	mds := birthday.MonthDays(filter.From, filter.To)
+	res := make([]dto.CustomerBirthday, 0, limit)
+	for len(res) < limit {
+		page, err := s.repo.GetByBirthdayMonthDays(ctx, mds, after, limit)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get customers", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		for _, c := range page {
+			if on, ok := birthday.Next(c.Birthday, timezone.LoadOrUTC(c.Timezone), filter.From, filter.To); ok {
+				res = append(res, dto.CustomerBirthday{Customer: c, On: on})
+				if len(res) == limit {
+					break
+				}
+			}
+		}
+		if len(page) < limit {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+
+	return res, nil
+}
+
+// CheckAge reports whether customer is at least minAge years old today in
+// their timezone.
+func (s *Service) CheckAge(ctx context.Context, id uuid.UUID, minAge int) (bool, error) {
+	const op = "service.customer.CheckAge"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := dto.ValidateMinAge(minAge); err != nil {
+		return false, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) {
+			tracing.RecordError(span, err)
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if customer.ErasedAt != nil {
+		return false, fmt.Errorf("%s: %w", op, storage.ErrCustomerErased)
+	}
+
+	today := time.Now().In(timezone.LoadOrUTC(customer.Timezone))
+	return birthday.Age(customer.Birthday, today) >= minAge, nil
+}
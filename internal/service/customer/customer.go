@@ -2,11 +2,20 @@ package customer
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"time"
 
 	"user-service/internal/domain/dto"
 	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/service"
+	attributesService "user-service/internal/service/attributes"
+	"user-service/internal/storage"
 
 	"github.com/google/uuid"
 )
@@ -14,61 +23,99 @@ import (
 type CustomerRepository interface {
 	Create(ctx context.Context, customer *models.Customer) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error)
+	GetByIDForWrite(ctx context.Context, id uuid.UUID) (*models.Customer, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Customer, error)
-	GetAll(ctx context.Context) ([]models.Customer, error)
+	GetAll(ctx context.Context, filter dto.CustomerFilter) ([]models.Customer, error)
+	Stream(ctx context.Context, filter dto.CustomerFilter, fn func(*models.Customer) error) error
 	Update(ctx context.Context, id uuid.UUID, customer *models.Customer) error
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Customer, error)
+	GetByBirthdayMonthDays(ctx context.Context, mds []int16, after uuid.UUID, limit int) ([]models.Customer, error)
+	WriteBatch(ctx context.Context, creates, updates []models.Customer) error
+	Erase(ctx context.Context, cert *models.ErasureCertificate) error
+	GetErasureCertificate(ctx context.Context, customerID uuid.UUID) (*models.ErasureCertificate, error)
+}
+
+// AttributeSchemas provides schemas custom attributes are validated against.
+type AttributeSchemas interface {
+	Registry(ctx context.Context) (*attributesService.Registry, error)
+}
+
+// Genders provides gender codes customers can be written with.
+type Genders interface {
+	Allowed(ctx context.Context) (dto.GenderSet, error)
 }
 
 type Service struct {
-	log  *slog.Logger
-	repo CustomerRepository
+	log        *slog.Logger
+	repo       CustomerRepository
+	attributes AttributeSchemas
+	genders    Genders
 }
 
-func New(log *slog.Logger, repo CustomerRepository) *Service {
+func New(log *slog.Logger, repo CustomerRepository, attributes AttributeSchemas, genders Genders) *Service {
 	return &Service{
-		log:  log,
-		repo: repo,
+		log:        log,
+		repo:       repo,
+		attributes: attributes,
+		genders:    genders,
 	}
 }
 
 func (s *Service) CreateCustomer(ctx context.Context, req *dto.CreateCustomerRequest) (*models.Customer, error) {
 	const op = "service.customer.CreateCustomer"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	log := s.log.With(slog.String("op", op))
 
 	if err := req.Validate(); err != nil {
-		log.Warn("validation failed", slog.String("error", err.Error()))
+		log.WarnContext(ctx, "validation failed", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.validateAttributes(ctx, req.Attributes); err != nil {
+		log.WarnContext(ctx, "attributes validation failed", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.validateGender(ctx, req.Gender); err != nil {
+		log.WarnContext(ctx, "gender validation failed", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	userID, err := uuid.Parse(req.UserID)
 	if err != nil {
-		log.Warn("invalid user_id", slog.String("error", err.Error()))
+		log.WarnContext(ctx, "invalid user_id", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("%s: invalid user_id: %w", op, err)
 	}
 
 	birthday, err := req.ParseBirthday()
 	if err != nil {
-		log.Warn("invalid birthday", slog.String("error", err.Error()))
+		log.WarnContext(ctx, "invalid birthday", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("%s: invalid birthday: %w", op, err)
 	}
 
 	customer := &models.Customer{
-		ID:        uuid.New(),
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Gender:    req.Gender,
-		Timezone:  req.Timezone,
-		Birthday:  birthday,
-		UserID:    userID,
+		ID:         uuid.New(),
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+		Gender:     req.Gender,
+		Timezone:   req.Timezone,
+		Birthday:   birthday,
+		UserID:     userID,
+		Attributes: models.Attributes(req.Attributes),
 	}
 
 	if err := s.repo.Create(ctx, customer); err != nil {
-		log.Error("failed to create customer", slog.String("error", err.Error()))
+		log.ErrorContext(ctx, "failed to create customer", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.Info("customer created", slog.String("customer_id", customer.ID.String()))
+	metrics.CustomersCreated.Inc()
+	log.InfoContext(ctx, "customer created", slog.String("customer_id", customer.ID.String()))
 
 	return customer, nil
 }
@@ -76,76 +123,274 @@ func (s *Service) CreateCustomer(ctx context.Context, req *dto.CreateCustomerReq
 func (s *Service) GetCustomer(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
 	const op = "service.customer.GetCustomer"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	log := s.log.With(slog.String("op", op))
 
 	customer, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		log.Error("failed to get customer", slog.String("error", err.Error()))
+		log.ErrorContext(ctx, "failed to get customer", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return customer, nil
 }
 
-func (s *Service) GetAllCustomers(ctx context.Context) ([]models.Customer, error) {
+func (s *Service) GetAllCustomers(ctx context.Context, filter dto.CustomerFilter) ([]models.Customer, error) {
 	const op = "service.customer.GetAllCustomers"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	log := s.log.With(slog.String("op", op))
 
-	customers, err := s.repo.GetAll(ctx)
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+	if err := s.resolveFilter(ctx, &filter); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	customers, err := s.repo.GetAll(ctx, filter)
 	if err != nil {
-		log.Error("failed to get customers", slog.String("error", err.Error()))
+		log.ErrorContext(ctx, "failed to get customers", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.Info("customers retrieved", slog.Int("count", len(customers)))
+	log.InfoContext(ctx, "customers retrieved", slog.Int("count", len(customers)))
 
 	return customers, nil
 }
 
+// ExportCustomers streams customers matching filter into fn without
+// loading them into memory. Customer passed to fn is only valid during the call.
+func (s *Service) ExportCustomers(ctx context.Context, filter dto.CustomerFilter, fn func(*models.Customer) error) error {
+	const op = "service.customer.ExportCustomers"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := filter.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+	if err := s.resolveFilter(ctx, &filter); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	count := 0
+	err := s.repo.Stream(ctx, filter, func(c *models.Customer) error {
+		count++
+		return fn(c)
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.ErrorContext(ctx, "export failed", slog.String("error", err.Error()), slog.Int("count", count))
+		}
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "customers exported", slog.Int("count", count))
+
+	return nil
+}
+
 func (s *Service) UpdateCustomer(ctx context.Context, id uuid.UUID, req *dto.UpdateCustomerRequest) (*models.Customer, error) {
 	const op = "service.customer.UpdateCustomer"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	log := s.log.With(slog.String("op", op))
 
 	if err := req.Validate(); err != nil {
-		log.Warn("validation failed", slog.String("error", err.Error()))
+		log.WarnContext(ctx, "validation failed", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.validateAttributes(ctx, req.Attributes); err != nil {
+		log.WarnContext(ctx, "attributes validation failed", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	existingCustomer, err := s.repo.GetByID(ctx, id)
+	existingCustomer, err := s.repo.GetByIDForWrite(ctx, id)
 	if err != nil {
-		log.Error("failed to get customer", slog.String("error", err.Error()))
+		log.ErrorContext(ctx, "failed to get customer", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if existingCustomer.ErasedAt != nil {
+		log.WarnContext(ctx, "update of erased customer rejected", slog.String("customer_id", id.String()))
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrCustomerErased)
+	}
+
+	// a deactivated value the customer already has is kept
+	if req.Gender != nil && *req.Gender != existingCustomer.Gender {
+		if err := s.validateGender(ctx, *req.Gender); err != nil {
+			log.WarnContext(ctx, "gender validation failed", slog.String("error", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := applyUpdate(existingCustomer, req); err != nil {
+		log.WarnContext(ctx, "invalid birthday", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.Update(ctx, id, existingCustomer); err != nil {
+		log.ErrorContext(ctx, "failed to update customer", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	metrics.CustomersUpdated.Inc()
+	log.InfoContext(ctx, "customer updated", slog.String("customer_id", id.String()))
+
+	return existingCustomer, nil
+}
+
+// EraseCustomer anonymizes customer PII and issues erasure certificate.
+// Erased customers can no longer be updated.
+func (s *Service) EraseCustomer(ctx context.Context, id uuid.UUID, req *dto.EraseCustomerRequest) (*models.ErasureCertificate, error) {
+	const op = "service.customer.EraseCustomer"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+
+	cert := &models.ErasureCertificate{
+		ID:         uuid.New(),
+		CustomerID: id,
+		Actor:      req.Actor,
+		Reason:     req.Reason,
+		ErasedAt:   time.Now().UTC(),
+	}
+
+	if err := s.repo.Erase(ctx, cert); err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) && !errors.Is(err, storage.ErrCustomerErased) {
+			log.ErrorContext(ctx, "failed to erase customer", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	metrics.CustomersErased.Inc()
+	log.InfoContext(ctx, "customer erased",
+		slog.String("customer_id", id.String()),
+		slog.String("certificate_id", cert.ID.String()),
+		slog.String("actor", cert.Actor),
+	)
+
+	return cert, nil
+}
+
+func (s *Service) GetErasureCertificate(ctx context.Context, id uuid.UUID) (*models.ErasureCertificate, error) {
+	const op = "service.customer.GetErasureCertificate"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	cert, err := s.repo.GetErasureCertificate(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cert, nil
+}
+
+// applyUpdate copies set fields of req into customer.
+func applyUpdate(customer *models.Customer, req *dto.UpdateCustomerRequest) error {
 	if req.FirstName != nil {
-		existingCustomer.FirstName = *req.FirstName
+		customer.FirstName = *req.FirstName
 	}
 	if req.LastName != nil {
-		existingCustomer.LastName = *req.LastName
+		customer.LastName = *req.LastName
 	}
 	if req.Gender != nil {
-		existingCustomer.Gender = *req.Gender
+		customer.Gender = *req.Gender
 	}
 	if req.Timezone != nil {
-		existingCustomer.Timezone = *req.Timezone
+		customer.Timezone = *req.Timezone
 	}
 	if req.Birthday != nil {
 		birthday, err := req.ParseBirthday()
 		if err != nil {
-			log.Warn("invalid birthday", slog.String("error", err.Error()))
-			return nil, fmt.Errorf("%s: invalid birthday: %w", op, err)
+			return fmt.Errorf("invalid birthday: %w", err)
+		}
+		customer.Birthday = *birthday
+	}
+	if len(req.Attributes) > 0 {
+		attrs := maps.Clone(customer.Attributes)
+		if attrs == nil {
+			attrs = make(models.Attributes, len(req.Attributes))
+		}
+		for namespace, value := range req.Attributes {
+			if dto.IsNull(value) {
+				delete(attrs, namespace)
+			} else {
+				attrs[namespace] = value
+			}
 		}
-		existingCustomer.Birthday = *birthday
+		customer.Attributes = attrs
 	}
+	return nil
+}
 
-	if err := s.repo.Update(ctx, id, existingCustomer); err != nil {
-		log.Error("failed to update customer", slog.String("error", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, err)
+// validateAttributes checks attrs against registered schemas, returns
+// *service.ValidationError if they do not match.
+func (s *Service) validateAttributes(ctx context.Context, attrs map[string]json.RawMessage) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+	reg, err := s.attributes.Registry(ctx)
+	if err != nil {
+		return err
+	}
+	if err := reg.Validate(attrs); err != nil {
+		return &service.ValidationError{Err: err}
 	}
+	return nil
+}
 
-	log.Info("customer updated", slog.String("customer_id", id.String()))
+// validateGender checks code is an active value of the gender
+// enumeration, returns *service.ValidationError if it is not.
+func (s *Service) validateGender(ctx context.Context, code string) error {
+	allowed, err := s.genders.Allowed(ctx)
+	if err != nil {
+		return err
+	}
+	if err := allowed.Check(code); err != nil {
+		return &service.ValidationError{Err: err}
+	}
+	return nil
+}
 
-	return existingCustomer, nil
+// resolveFilter types attribute filter values by their schemas.
+func (s *Service) resolveFilter(ctx context.Context, filter *dto.CustomerFilter) error {
+	if len(filter.Attributes) == 0 {
+		return nil
+	}
+	reg, err := s.attributes.Registry(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range filter.Attributes {
+		if err := reg.ResolveFilter(&filter.Attributes[i]); err != nil {
+			return &service.ValidationError{Err: err}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,158 @@
+package dsar
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"user-service/internal/domain/models"
+)
+
+// writeArchive writes zip with one JSON file per data category and summary.txt.
+func writeArchive(w io.Writer, data *models.CustomerData, generatedAt time.Time) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"profile.json", data.Customer},
+		{"addresses.json", data.Addresses},
+		{"favorites.json", data.Favorites},
+		{"change_history.json", data.Changes},
+		{"consents.json", data.Consents},
+		{"preferences.json", data.Preferences},
+		{"tags.json", data.Tags},
+		{"birthday_greetings.json", data.Greetings},
+	}
+
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: generatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.v); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     "summary.txt",
+		Method:   zip.Deflate,
+		Modified: generatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(fw, summary(data, generatedAt)); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func summary(data *models.CustomerData, generatedAt time.Time) string {
+	var b strings.Builder
+	c := data.Customer
+
+	fmt.Fprintf(&b, "Personal data report\n")
+	fmt.Fprintf(&b, "Generated at: %s\n\n", generatedAt.Format(time.RFC1123))
+
+	fmt.Fprintf(&b, "Profile\n")
+	fmt.Fprintf(&b, "  Customer ID:    %s\n", c.ID)
+	fmt.Fprintf(&b, "  User ID:        %s\n", c.UserID)
+	fmt.Fprintf(&b, "  First name:     %s\n", c.FirstName)
+	fmt.Fprintf(&b, "  Last name:      %s\n", c.LastName)
+	fmt.Fprintf(&b, "  Gender:         %s\n", c.Gender)
+	fmt.Fprintf(&b, "  Birthday:       %s\n", c.Birthday.Format("2 January 2006"))
+	fmt.Fprintf(&b, "  Timezone:       %s\n", c.Timezone)
+	fmt.Fprintf(&b, "  Customer since: %s\n\n", c.CreatedAt.UTC().Format(time.RFC1123))
+
+	fmt.Fprintf(&b, "Addresses (%d)\n", len(data.Addresses))
+	for i, a := range data.Addresses {
+		fmt.Fprintf(&b, "  %d. %s, apartment %s, floor %d", i+1, a.Address, a.Apartment, a.Floor)
+		if a.Comments != "" {
+			fmt.Fprintf(&b, " (%s)", a.Comments)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "Favorite products (%d)\n", len(data.Favorites))
+	for _, f := range data.Favorites {
+		fmt.Fprintf(&b, "  %s, added %s\n", f.ProductID, f.CreatedAt.UTC().Format("2 January 2006"))
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "Change history (%d entries)\n", len(data.Changes))
+	for _, ch := range data.Changes {
+		fmt.Fprintf(&b, "  %s  %s\n", ch.ChangedAt.UTC().Format(time.RFC3339), ch.Operation)
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "Consent records (%d)\n", len(data.Consents))
+	for _, c := range data.Consents {
+		fmt.Fprintf(&b, "  %s  %s %s (source %s, terms version %s)\n",
+			c.RecordedAt.UTC().Format(time.RFC3339), c.Purpose, c.Status, c.Source, c.LegalBasisVersion)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("Preferences\n")
+	if p := data.Preferences; p == nil {
+		b.WriteString("  none set, defaults apply\n")
+	} else {
+		fmt.Fprintf(&b, "  Locale:         %s\n", orDefault(p.Locale))
+		fmt.Fprintf(&b, "  Currency:       %s\n", orDefault(p.Currency))
+		if p.NotificationChannels == nil {
+			fmt.Fprintf(&b, "  Channels:       default\n")
+		} else {
+			fmt.Fprintf(&b, "  Channels:       %s\n", strings.Join(p.NotificationChannels, ", "))
+		}
+		if p.QuietHoursStart != nil && p.QuietHoursEnd != nil {
+			fmt.Fprintf(&b, "  Quiet hours:    %s-%s\n", *p.QuietHoursStart, *p.QuietHoursEnd)
+		} else {
+			fmt.Fprintf(&b, "  Quiet hours:    off\n")
+		}
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "Tags (%d)\n", len(data.Tags))
+	for _, t := range data.Tags {
+		fmt.Fprintf(&b, "  %s, added %s\n", t.Tag, t.CreatedAt.UTC().Format("2 January 2006"))
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "Birthday greetings (%d)\n", len(data.Greetings))
+	for _, g := range data.Greetings {
+		fmt.Fprintf(&b, "  %d, sent %s\n", g.Year, g.SentAt.UTC().Format(time.RFC3339))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("Files\n")
+	b.WriteString("  profile.json             customer profile\n")
+	b.WriteString("  addresses.json           delivery addresses\n")
+	b.WriteString("  favorites.json           favorite products\n")
+	b.WriteString("  change_history.json      every change made to the profile\n")
+	b.WriteString("  consents.json            consent records\n")
+	b.WriteString("  preferences.json         preferences as set, null when defaults apply\n")
+	b.WriteString("  tags.json                tags attached to the profile\n")
+	b.WriteString("  birthday_greetings.json  birthday greetings sent\n")
+
+	return b.String()
+}
+
+func orDefault(v *string) string {
+	if v == nil {
+		return "default"
+	}
+	return *v
+}
@@ -0,0 +1,326 @@
+package dsar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"user-service/internal/config"
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/signedurl"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrQueueFull = errors.New("dsar queue is full")
+	// ErrNotReady archive is not built yet or has already been removed.
+	ErrNotReady = errors.New("dsar archive is not available")
+)
+
+type DSARRepository interface {
+	Create(ctx context.Context, req *models.DSARRequest) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DSARRequest, error)
+	UpdateStatus(ctx context.Context, req *models.DSARRequest) error
+	FailUnfinished(ctx context.Context, reason string) (int64, error)
+	ExpireCompleted(ctx context.Context, before time.Time) ([]uuid.UUID, error)
+	CollectCustomerData(ctx context.Context, customerID uuid.UUID) (*models.CustomerData, error)
+}
+
+type Service struct {
+	log    *slog.Logger
+	repo   DSARRepository
+	cfg    config.DSARConfig
+	secret string
+	queue  chan *models.DSARRequest
+}
+
+// New creates service, download links are signed with secret.
+func New(log *slog.Logger, repo DSARRepository, cfg config.DSARConfig, secret string) *Service {
+	return &Service{
+		log:    log,
+		repo:   repo,
+		cfg:    cfg,
+		secret: secret,
+		queue:  make(chan *models.DSARRequest, cfg.QueueSize),
+	}
+}
+
+// Request registers access request for customer and queues archive build.
+func (s *Service) Request(ctx context.Context, customerID uuid.UUID) (*models.DSARRequest, error) {
+	const op = "service.dsar.Request"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	req := &models.DSARRequest{
+		ID:         uuid.New(),
+		CustomerID: customerID,
+		Status:     models.DSARQueued,
+	}
+	if err := s.repo.Create(ctx, req); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	select {
+	case s.queue <- req:
+	default:
+		s.finish(context.WithoutCancel(ctx), req, ErrQueueFull)
+		return nil, fmt.Errorf("%s: %w", op, ErrQueueFull)
+	}
+
+	log.InfoContext(ctx, "dsar queued",
+		slog.String("dsar_id", req.ID.String()),
+		slog.String("customer_id", customerID.String()),
+	)
+
+	return req, nil
+}
+
+// GetRequest returns request status, completed requests get a fresh download link.
+func (s *Service) GetRequest(ctx context.Context, id uuid.UUID) (*models.DSARRequest, error) {
+	const op = "service.dsar.GetRequest"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	req, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if req.Status == models.DSARCompleted {
+		expires := time.Now().Add(s.cfg.LinkTTL).Truncate(time.Second)
+		req.DownloadURL = signedurl.Sign(s.secret, DownloadPath(id), expires)
+		req.URLExpires = &expires
+	}
+
+	return req, nil
+}
+
+// OpenArchive checks download link and opens archive, caller closes the file.
+func (s *Service) OpenArchive(ctx context.Context, id uuid.UUID, query url.Values) (*os.File, error) {
+	const op = "service.dsar.OpenArchive"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := signedurl.Verify(s.secret, DownloadPath(id), query, time.Now()); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	req, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if req.Status != models.DSARCompleted {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotReady)
+	}
+
+	f, err := os.Open(s.archivePath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s: %w", op, ErrNotReady)
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return f, nil
+}
+
+// DownloadPath is the path signed into download links.
+func DownloadPath(id uuid.UUID) string {
+	return "/api/v1/dsar/" + id.String() + "/download"
+}
+
+// Run fails requests left from a previous process, processes queued
+// requests with cfg.Workers goroutines and removes archives older than
+// cfg.Retention until ctx is done.
+func (s *Service) Run(ctx context.Context) {
+	const op = "service.dsar.Run"
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := os.MkdirAll(s.cfg.Dir, 0o700); err != nil {
+		log.Error("failed to create dsar directory", slog.String("error", err.Error()))
+	}
+
+	if n, err := s.repo.FailUnfinished(ctx, "interrupted by service restart"); err != nil {
+		log.Error("failed to mark unfinished dsar requests", slog.String("error", err.Error()))
+	} else if n > 0 {
+		log.Warn("unfinished dsar requests marked as failed", slog.Int64("count", n))
+	}
+
+	workers := max(s.cfg.Workers, 1)
+	done := make(chan struct{})
+	for range workers {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case req := <-s.queue:
+					s.process(ctx, req)
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(s.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			for range workers {
+				<-done
+			}
+			return
+		case <-ticker.C:
+			s.purge(ctx)
+		}
+	}
+}
+
+func (s *Service) process(ctx context.Context, req *models.DSARRequest) {
+	const op = "service.dsar.process"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op), slog.String("dsar_id", req.ID.String()))
+
+	req.Status = models.DSARRunning
+	if err := s.repo.UpdateStatus(ctx, req); err != nil {
+		log.ErrorContext(ctx, "failed to update dsar request", slog.String("error", err.Error()))
+	}
+
+	err := s.build(ctx, req)
+	if err != nil {
+		log.ErrorContext(ctx, "dsar failed", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+	}
+	s.finish(context.WithoutCancel(ctx), req, err)
+
+	log.InfoContext(ctx, "dsar finished", slog.String("status", req.Status))
+}
+
+// build writes archive to a temporary file and renames it into place,
+// so a partially written archive is never served.
+func (s *Service) build(ctx context.Context, req *models.DSARRequest) error {
+	data, err := s.repo.CollectCustomerData(ctx, req.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.cfg.Dir, "dsar-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := writeArchive(f, data, time.Now().UTC()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.archivePath(req.ID))
+}
+
+func (s *Service) purge(ctx context.Context) {
+	const op = "service.dsar.purge"
+
+	log := s.log.With(slog.String("op", op))
+
+	ids, err := s.repo.ExpireCompleted(ctx, time.Now().Add(-s.cfg.Retention))
+	if err != nil {
+		log.Error("failed to expire dsar requests", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, id := range ids {
+		if err := os.Remove(s.archivePath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Error("failed to remove dsar archive", slog.String("dsar_id", id.String()), slog.String("error", err.Error()))
+		}
+	}
+	if len(ids) > 0 {
+		log.Info("expired dsar archives removed", slog.Int("count", len(ids)))
+	}
+
+	s.removeOrphans(ctx)
+}
+
+// removeOrphans removes archives whose request is gone, e.g. deleted
+// together with an erased customer, or has expired or failed.
+func (s *Service) removeOrphans(ctx context.Context) {
+	log := s.log.With(slog.String("op", "service.dsar.removeOrphans"))
+
+	files, err := filepath.Glob(filepath.Join(s.cfg.Dir, "*.zip"))
+	if err != nil {
+		log.Error("failed to list dsar archives", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, file := range files {
+		id, err := uuid.Parse(strings.TrimSuffix(filepath.Base(file), ".zip"))
+		if err != nil {
+			continue
+		}
+		req, err := s.repo.GetByID(ctx, id)
+		if err != nil && !errors.Is(err, storage.ErrDSARNotFound) {
+			log.Error("failed to get dsar request", slog.String("dsar_id", id.String()), slog.String("error", err.Error()))
+			return
+		}
+		// running request may have just renamed its archive into place
+		if req != nil && req.Status != models.DSARExpired && req.Status != models.DSARFailed {
+			continue
+		}
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Error("failed to remove dsar archive", slog.String("dsar_id", id.String()), slog.String("error", err.Error()))
+			continue
+		}
+		log.Info("orphaned dsar archive removed", slog.String("dsar_id", id.String()))
+	}
+}
+
+func (s *Service) archivePath(id uuid.UUID) string {
+	return filepath.Join(s.cfg.Dir, id.String()+".zip")
+}
+
+// finish stores final status, err == nil means completed.
+func (s *Service) finish(ctx context.Context, req *models.DSARRequest, err error) {
+	now := time.Now()
+	req.FinishedAt = &now
+	req.Status = models.DSARCompleted
+	req.Error = nil
+	if err != nil {
+		msg := err.Error()
+		req.Status = models.DSARFailed
+		req.Error = &msg
+	}
+
+	if err := s.repo.UpdateStatus(ctx, req); err != nil {
+		s.log.ErrorContext(ctx, "failed to save dsar result",
+			slog.String("dsar_id", req.ID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
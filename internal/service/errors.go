@@ -0,0 +1,11 @@
+// Package service holds what the service packages share.
+package service
+
+// ValidationError request failed validation, message is safe to return to client.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string { return e.Err.Error() }
+
+func (e *ValidationError) Unwrap() error { return e.Err }
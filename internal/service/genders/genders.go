@@ -0,0 +1,107 @@
+package genders
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/service"
+)
+
+type GenderRepository interface {
+	List(ctx context.Context) ([]models.Gender, error)
+	Save(ctx context.Context, gender *models.Gender) error
+}
+
+type Service struct {
+	log  *slog.Logger
+	repo GenderRepository
+}
+
+func New(log *slog.Logger, repo GenderRepository) *Service {
+	return &Service{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// ListGenders returns the enumeration ordered by position, inactive
+// values only when all is set.
+func (s *Service) ListGenders(ctx context.Context, all bool) ([]models.Gender, error) {
+	const op = "service.genders.ListGenders"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	genders, err := s.repo.List(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if all {
+		return genders, nil
+	}
+
+	active := genders[:0]
+	for _, g := range genders {
+		if g.Active {
+			active = append(active, g)
+		}
+	}
+	return active, nil
+}
+
+// SaveGender adds value to the enumeration or replaces it. Values cannot
+// be removed because customers reference them, they are deactivated.
+func (s *Service) SaveGender(ctx context.Context, code string, req *dto.GenderRequest) (*models.Gender, error) {
+	const op = "service.genders.SaveGender"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	code, err := dto.NormalizeGender(code)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+
+	gender := &models.Gender{
+		Code:     code,
+		Label:    req.Label,
+		Position: req.Position,
+		Active:   *req.Active,
+	}
+	if err := s.repo.Save(ctx, gender); err != nil {
+		log.ErrorContext(ctx, "failed to save gender", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "gender saved", slog.String("code", code), slog.Bool("active", gender.Active))
+
+	return gender, nil
+}
+
+// Allowed returns active codes customers can be written with. Codes are
+// loaded on every call so changes take effect on every instance at once.
+func (s *Service) Allowed(ctx context.Context) (dto.GenderSet, error) {
+	const op = "service.genders.Allowed"
+
+	genders, err := s.ListGenders(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	set := make(dto.GenderSet, len(genders))
+	for _, g := range genders {
+		set[g.Code] = true
+	}
+	return set, nil
+}
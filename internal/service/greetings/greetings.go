@@ -0,0 +1,198 @@
+package greetings
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"user-service/internal/config"
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/birthday"
+	"user-service/internal/lib/timezone"
+	"user-service/internal/lib/tracing"
+
+	"github.com/google/uuid"
+)
+
+type CustomerRepository interface {
+	GetByBirthdayMonthDays(ctx context.Context, mds []int16, after uuid.UUID, limit int) ([]models.Customer, error)
+}
+
+type GreetingRepository interface {
+	Claim(ctx context.Context, greetings []models.BirthdayGreeting) ([]uuid.UUID, error)
+	Unclaim(ctx context.Context, customerID uuid.UUID, year int) error
+}
+
+// Preferences resolves notification preferences of customers.
+type Preferences interface {
+	GetManyPreferences(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Preferences, error)
+}
+
+// Notifier delivers greetings to customers through the channels they chose.
+type Notifier interface {
+	SendBirthdayGreeting(ctx context.Context, customer models.Customer, channels []string) error
+}
+
+// LogNotifier writes greetings to the log, used until a delivery channel
+// is configured.
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+func (n *LogNotifier) SendBirthdayGreeting(ctx context.Context, customer models.Customer, channels []string) error {
+	n.log.InfoContext(ctx, "birthday greeting sent",
+		slog.String("customer_id", customer.ID.String()),
+		slog.String("timezone", customer.Timezone),
+		slog.Any("channels", channels),
+	)
+	return nil
+}
+
+// NewNotifier returns notifier configured by cfg.Notifier.
+func NewNotifier(cfg config.SchedulerConfig, log *slog.Logger) (Notifier, error) {
+	switch cfg.Notifier {
+	case "log", "":
+		return NewLogNotifier(log), nil
+	default:
+		return nil, fmt.Errorf("unknown scheduler notifier %q", cfg.Notifier)
+	}
+}
+
+// Service greets customers on their birthday. A greeting is recorded
+// before it is sent and removed again if sending fails, so a customer is
+// greeted at most once a year even when replicas overlap.
+type Service struct {
+	log       *slog.Logger
+	customers CustomerRepository
+	repo      GreetingRepository
+	prefs     Preferences
+	notifier  Notifier
+	cfg       config.SchedulerConfig
+}
+
+func New(log *slog.Logger, customers CustomerRepository, repo GreetingRepository, prefs Preferences, notifier Notifier, cfg config.SchedulerConfig) *Service {
+	return &Service{
+		log:       log,
+		customers: customers,
+		repo:      repo,
+		prefs:     prefs,
+		notifier:  notifier,
+		cfg:       cfg,
+	}
+}
+
+// SendBirthdayGreetings greets customers whose birthday is today in their
+// timezone and whose local time has reached cfg.GreetingHour(). Greetings
+// missed while the job was not running or held back by quiet hours go out
+// later the same local day. Customers without notification channels are
+// not greeted.
+func (s *Service) SendBirthdayGreetings(ctx context.Context) error {
+	const op = "service.greetings.SendBirthdayGreetings"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	now := time.Now()
+	mds := birthday.MonthDays(now, now)
+	batch := max(s.cfg.BatchSize, 1)
+
+	var (
+		after uuid.UUID
+		sent  int
+	)
+	for {
+		page, err := s.customers.GetByBirthdayMonthDays(ctx, mds, after, batch)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		customers := make(map[uuid.UUID]models.Customer)
+		years := make(map[uuid.UUID]int)
+		ids := make([]uuid.UUID, 0, len(page))
+		for _, c := range page {
+			if year, ok := s.due(c, now); ok {
+				customers[c.ID] = c
+				years[c.ID] = year
+				ids = append(ids, c.ID)
+			}
+		}
+
+		var (
+			due   []models.BirthdayGreeting
+			prefs map[uuid.UUID]*models.Preferences
+		)
+		if len(ids) > 0 {
+			prefs, err = s.prefs.GetManyPreferences(ctx, ids)
+			if err != nil {
+				tracing.RecordError(span, err)
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		for _, id := range ids {
+			p := prefs[id]
+			if p == nil || len(p.NotificationChannels) == 0 {
+				continue
+			}
+			if p.QuietHours != nil && p.QuietHours.Contains(now) {
+				continue
+			}
+			due = append(due, models.BirthdayGreeting{CustomerID: id, Year: years[id]})
+		}
+
+		if len(due) > 0 {
+			claimed, err := s.repo.Claim(ctx, due)
+			if err != nil {
+				tracing.RecordError(span, err)
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			for _, id := range claimed {
+				if err := s.notifier.SendBirthdayGreeting(ctx, customers[id], prefs[id].NotificationChannels); err != nil {
+					log.ErrorContext(ctx, "failed to send birthday greeting",
+						slog.String("customer_id", id.String()),
+						slog.String("error", err.Error()),
+					)
+					if err := s.repo.Unclaim(ctx, id, years[id]); err != nil {
+						log.ErrorContext(ctx, "failed to unclaim birthday greeting, it will not be retried",
+							slog.String("customer_id", id.String()),
+							slog.String("error", err.Error()),
+						)
+					}
+					continue
+				}
+				sent++
+			}
+		}
+
+		if len(page) < batch {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+
+	if sent > 0 {
+		log.InfoContext(ctx, "birthday greetings sent", slog.Int("count", sent))
+	}
+	return nil
+}
+
+// due reports whether customer is to be greeted at now and the local
+// year of the birthday. Nobody is greeted on the day they were born.
+func (s *Service) due(c models.Customer, now time.Time) (int, bool) {
+	local := now.In(timezone.LoadOrUTC(c.Timezone))
+	if local.Year() <= c.Birthday.Year() || local.Hour() < s.cfg.GreetingHour() {
+		return 0, false
+	}
+	on := birthday.In(c.Birthday, local.Year(), local.Location())
+	if on.Month() != local.Month() || on.Day() != local.Day() {
+		return 0, false
+	}
+	return local.Year(), true
+}
@@ -0,0 +1,404 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"user-service/internal/config"
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	customerService "user-service/internal/service/customer"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported import format")
+	ErrQueueFull         = errors.New("import queue is full")
+)
+
+type ImportRepository interface {
+	Create(ctx context.Context, imp *models.Import) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Import, error)
+	UpdateProgress(ctx context.Context, imp *models.Import) error
+	FailUnfinished(ctx context.Context, reason string) (int64, error)
+	AddRowErrors(ctx context.Context, rowErrors []models.ImportRowError) error
+	StreamRowErrors(ctx context.Context, importID uuid.UUID, fn func(models.ImportRowError) error) error
+	CopyCustomers(ctx context.Context, customers []models.Customer) error
+}
+
+// Genders provides gender codes customers can be written with.
+type Genders interface {
+	Allowed(ctx context.Context) (dto.GenderSet, error)
+}
+
+// Customers writes single customers when a chunk is rejected as a whole.
+type Customers interface {
+	Create(ctx context.Context, customer *models.Customer) error
+}
+
+// StartParams new import job parameters. Mapping maps source column
+// (or NDJSON key) to CreateCustomerRequest json field name.
+type StartParams struct {
+	Format  string
+	DryRun  bool
+	Mapping map[string]string
+	Source  io.Reader
+}
+
+type job struct {
+	imp     *models.Import
+	path    string
+	mapping map[string]string
+}
+
+type Service struct {
+	log       *slog.Logger
+	repo      ImportRepository
+	customers Customers
+	genders   Genders
+	cfg       config.ImportsConfig
+	queue     chan job
+}
+
+func New(log *slog.Logger, repo ImportRepository, customers Customers, genders Genders, cfg config.ImportsConfig) *Service {
+	return &Service{
+		log:       log,
+		repo:      repo,
+		customers: customers,
+		genders:   genders,
+		cfg:       cfg,
+		queue:     make(chan job, cfg.QueueSize),
+	}
+}
+
+// Start spools source to disk, registers import and queues it for processing.
+func (s *Service) Start(ctx context.Context, params StartParams) (*models.Import, error) {
+	const op = "service.imports.Start"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if params.Format != models.ImportFormatCSV && params.Format != models.ImportFormatNDJSON {
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnsupportedFormat, params.Format)
+	}
+
+	f, err := os.CreateTemp(s.cfg.SpoolDir, "customers-import-*")
+	if err != nil {
+		log.ErrorContext(ctx, "failed to create spool file", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := io.Copy(f, params.Source); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: failed to read upload: %w", op, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	imp := &models.Import{
+		ID:     uuid.New(),
+		Status: models.ImportQueued,
+		Format: params.Format,
+		DryRun: params.DryRun,
+	}
+	if err := s.repo.Create(ctx, imp); err != nil {
+		os.Remove(f.Name())
+		log.ErrorContext(ctx, "failed to create import", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	select {
+	case s.queue <- job{imp: imp, path: f.Name(), mapping: params.Mapping}:
+	default:
+		os.Remove(f.Name())
+		s.finish(context.WithoutCancel(ctx), imp, ErrQueueFull)
+		return nil, fmt.Errorf("%s: %w", op, ErrQueueFull)
+	}
+
+	log.InfoContext(ctx, "import queued",
+		slog.String("import_id", imp.ID.String()),
+		slog.String("format", imp.Format),
+		slog.Bool("dry_run", imp.DryRun),
+	)
+
+	return imp, nil
+}
+
+func (s *Service) GetImport(ctx context.Context, id uuid.UUID) (*models.Import, error) {
+	const op = "service.imports.GetImport"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	imp, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return imp, nil
+}
+
+// StreamErrors calls fn for each rejected row of import.
+func (s *Service) StreamErrors(ctx context.Context, id uuid.UUID, fn func(models.ImportRowError) error) error {
+	const op = "service.imports.StreamErrors"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.StreamRowErrors(ctx, id, fn); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Run fails imports left from a previous process and processes queued
+// jobs with cfg.Workers goroutines until ctx is done.
+func (s *Service) Run(ctx context.Context) {
+	const op = "service.imports.Run"
+
+	log := s.log.With(slog.String("op", op))
+
+	if n, err := s.repo.FailUnfinished(ctx, "interrupted by service restart"); err != nil {
+		log.Error("failed to mark unfinished imports", slog.String("error", err.Error()))
+	} else if n > 0 {
+		log.Warn("unfinished imports marked as failed", slog.Int64("count", n))
+	}
+
+	workers := max(s.cfg.Workers, 1)
+	done := make(chan struct{})
+	for range workers {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-s.queue:
+					s.process(ctx, j)
+				}
+			}
+		}()
+	}
+	for range workers {
+		<-done
+	}
+}
+
+func (s *Service) process(ctx context.Context, j job) {
+	const op = "service.imports.process"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer os.Remove(j.path)
+
+	imp := j.imp
+	log := s.log.With(slog.String("op", op), slog.String("import_id", imp.ID.String()))
+
+	imp.Status = models.ImportRunning
+	if err := s.repo.UpdateProgress(ctx, imp); err != nil {
+		log.ErrorContext(ctx, "failed to update import", slog.String("error", err.Error()))
+	}
+
+	err := s.load(ctx, j)
+	if err != nil {
+		log.ErrorContext(ctx, "import failed", slog.String("error", err.Error()))
+		tracing.RecordError(span, err)
+	}
+	s.finish(context.WithoutCancel(ctx), imp, err)
+
+	log.InfoContext(ctx, "import finished",
+		slog.String("status", imp.Status),
+		slog.Int("total", imp.TotalRows),
+		slog.Int("imported", imp.ImportedRows),
+		slog.Int("rejected", imp.RejectedRows),
+	)
+}
+
+func (s *Service) load(ctx context.Context, j job) error {
+	f, err := os.Open(j.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var rows rowReader
+	switch j.imp.Format {
+	case models.ImportFormatCSV:
+		rows, err = newCSVReader(f, j.mapping)
+		if err != nil {
+			return err
+		}
+	default:
+		rows = newNDJSONReader(f, j.mapping)
+	}
+
+	// enumeration changes during the import are not picked up
+	genders, err := s.genders.Allowed(ctx)
+	if err != nil {
+		return err
+	}
+
+	chunkSize := max(s.cfg.ChunkSize, 1)
+	var (
+		customers = make([]models.Customer, 0, chunkSize)
+		sources   = make([]row, 0, chunkSize)
+		rejected  []models.ImportRowError
+	)
+
+	reject := func(r row, field, reason string) {
+		rejected = append(rejected, models.ImportRowError{
+			ImportID:  j.imp.ID,
+			RowNumber: r.number,
+			Field:     field,
+			Error:     reason,
+		})
+	}
+
+	flush := func() error {
+		if len(customers) > 0 && !j.imp.DryRun {
+			if err := s.repo.CopyCustomers(ctx, customers); err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				// one bad row fails the whole copy, find it by writing one by one
+				s.log.WarnContext(ctx, "chunk copy failed, falling back to single writes",
+					slog.String("import_id", j.imp.ID.String()),
+					slog.String("error", err.Error()),
+				)
+				written := customers[:0]
+				for i := range customers {
+					if err := s.customers.Create(ctx, &customers[i]); err != nil {
+						if ctx.Err() != nil {
+							return err
+						}
+						reject(sources[i], "", "rejected by database: "+err.Error())
+						continue
+					}
+					written = append(written, customers[i])
+				}
+				customers = written
+			}
+		}
+
+		j.imp.ImportedRows += len(customers)
+		j.imp.RejectedRows += len(rejected)
+		if !j.imp.DryRun {
+			metrics.CustomersCreated.Add(float64(len(customers)))
+		}
+
+		if err := s.repo.AddRowErrors(ctx, rejected); err != nil {
+			return err
+		}
+		if err := s.repo.UpdateProgress(ctx, j.imp); err != nil {
+			return err
+		}
+
+		customers = customers[:0]
+		sources = sources[:0]
+		rejected = rejected[:0]
+		return nil
+	}
+
+	for {
+		r, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		j.imp.TotalRows++
+
+		if field, err := validate(r, genders); err != nil {
+			reject(r, field, err.Error())
+		} else if c, err := customerService.NewCustomer(r.req); err != nil {
+			reject(r, failedField(err), err.Error())
+		} else {
+			customers = append(customers, *c)
+			sources = append(sources, r)
+		}
+
+		if len(customers)+len(rejected) >= chunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+// validate checks mapped row and returns the request field that failed
+// when it is known.
+func validate(r row, genders dto.GenderSet) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	if err := r.req.Validate(); err != nil {
+		return failedField(err), err
+	}
+	if err := genders.Check(r.req.Gender); err != nil {
+		return "gender", err
+	}
+	return "", nil
+}
+
+// rowFields request fields rows are mapped to, see setField.
+var rowFields = []string{"first_name", "last_name", "gender", "timezone", "birthday", "user_id", "attributes"}
+
+// failedField finds request field named by validation error, messages
+// start with the field name like "birthday cannot be in the future".
+func failedField(err error) string {
+	msg := strings.TrimPrefix(err.Error(), "invalid ")
+	for _, f := range rowFields {
+		if strings.HasPrefix(msg, f) {
+			return f
+		}
+	}
+	return ""
+}
+
+// finish stores final status, err == nil means completed.
+func (s *Service) finish(ctx context.Context, imp *models.Import, err error) {
+	now := time.Now()
+	imp.FinishedAt = &now
+	imp.Status = models.ImportCompleted
+	imp.Error = nil
+	if err != nil {
+		msg := err.Error()
+		imp.Status = models.ImportFailed
+		imp.Error = &msg
+	}
+
+	if err := s.repo.UpdateProgress(ctx, imp); err != nil {
+		s.log.ErrorContext(ctx, "failed to save import result",
+			slog.String("import_id", imp.ID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
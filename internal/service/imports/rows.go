@@ -0,0 +1,166 @@
+package imports
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"user-service/internal/domain/dto"
+)
+
+// row single source record mapped to create request.
+type row struct {
+	number int
+	req    *dto.CreateCustomerRequest
+	err    error // mapping error, row is rejected
+}
+
+// rowReader yields rows until io.EOF.
+type rowReader interface {
+	Next() (row, error)
+}
+
+// setField sets CreateCustomerRequest field by its json name.
+func setField(req *dto.CreateCustomerRequest, field, value string) bool {
+	value = strings.TrimSpace(value)
+	switch field {
+	case "first_name":
+		req.FirstName = value
+	case "last_name":
+		req.LastName = value
+	case "gender":
+		req.Gender = strings.ToLower(value)
+	case "timezone":
+		req.Timezone = value
+	case "birthday":
+		req.Birthday = value
+	case "user_id":
+		req.UserID = value
+	default:
+		return false
+	}
+	return true
+}
+
+// normalizeColumn "First Name" -> "first_name"
+func normalizeColumn(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+}
+
+// resolveField maps source column to request field using explicit mapping first.
+func resolveField(mapping map[string]string, column string) string {
+	if f, ok := mapping[column]; ok {
+		return f
+	}
+	return normalizeColumn(column)
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	fields  []string
+	lineNum int
+}
+
+func newCSVReader(src io.Reader, mapping map[string]string) (*csvReader, error) {
+	r := csv.NewReader(src)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = false
+
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv is empty")
+		}
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	fields := make([]string, len(header))
+	for i, col := range header {
+		fields[i] = resolveField(mapping, strings.TrimPrefix(col, "\ufeff"))
+	}
+
+	return &csvReader{r: r, fields: fields, lineNum: 1}, nil
+}
+
+func (c *csvReader) Next() (row, error) {
+	record, err := c.r.Read()
+	c.lineNum++
+	if errors.Is(err, io.EOF) {
+		return row{}, io.EOF
+	}
+
+	res := row{number: c.lineNum}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			res.err = parseErr.Err
+			return res, nil
+		}
+		return row{}, err
+	}
+
+	if len(record) != len(c.fields) {
+		res.err = fmt.Errorf("expected %d columns, got %d", len(c.fields), len(record))
+		return res, nil
+	}
+
+	req := &dto.CreateCustomerRequest{}
+	for i, value := range record {
+		setField(req, c.fields[i], value)
+	}
+	res.req = req
+
+	return res, nil
+}
+
+type ndjsonReader struct {
+	s       *bufio.Scanner
+	mapping map[string]string
+	lineNum int
+}
+
+const maxNDJSONLine = 1 << 20
+
+func newNDJSONReader(src io.Reader, mapping map[string]string) *ndjsonReader {
+	s := bufio.NewScanner(src)
+	s.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+	return &ndjsonReader{s: s, mapping: mapping}
+}
+
+func (n *ndjsonReader) Next() (row, error) {
+	for n.s.Scan() {
+		n.lineNum++
+		line := strings.TrimSpace(n.s.Text())
+		if line == "" {
+			continue
+		}
+
+		res := row{number: n.lineNum}
+
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			res.err = errors.New("invalid json")
+			return res, nil
+		}
+
+		req := &dto.CreateCustomerRequest{}
+		for key, value := range obj {
+			if value == nil {
+				continue
+			}
+			setField(req, resolveField(n.mapping, key), fmt.Sprint(value))
+		}
+		res.req = req
+
+		return res, nil
+	}
+
+	if err := n.s.Err(); err != nil {
+		return row{}, err
+	}
+	return row{}, io.EOF
+}
@@ -0,0 +1,162 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"user-service/internal/config"
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/tracing"
+)
+
+type OutboxRepository interface {
+	PublishPending(ctx context.Context, limit int, publish func(models.Event) error) (int, error)
+	PurgePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Publisher delivers events to downstream services.
+type Publisher interface {
+	Publish(ctx context.Context, event models.Event) error
+}
+
+// LogPublisher writes events to the log, used until a broker is configured.
+type LogPublisher struct {
+	log *slog.Logger
+}
+
+func NewLogPublisher(log *slog.Logger) *LogPublisher {
+	return &LogPublisher{log: log}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event models.Event) error {
+	p.log.InfoContext(ctx, "event published",
+		slog.String("event_id", event.ID.String()),
+		slog.String("type", event.Type),
+		slog.String("aggregate_id", event.AggregateID.String()),
+		slog.String("payload", string(event.Payload)),
+	)
+	return nil
+}
+
+// WebhookPublisher posts events as JSON to url, any status other than
+// 2xx is a failed delivery and the event is retried.
+type WebhookPublisher struct {
+	client *http.Client
+	url    string
+}
+
+func NewWebhookPublisher(client *http.Client, url string) *WebhookPublisher {
+	return &WebhookPublisher{client: client, url: url}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event models.Event) error {
+	const op = "service.outbox.WebhookPublisher.Publish"
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: unexpected status %d", op, resp.StatusCode)
+	}
+	return nil
+}
+
+// NewPublisher returns publisher configured by cfg.Publisher. HTTP
+// publishers use the tracing client so downstream spans join the trace.
+func NewPublisher(cfg config.EventsConfig, log *slog.Logger) (Publisher, error) {
+	switch cfg.Publisher {
+	case "log", "":
+		return NewLogPublisher(log), nil
+	case "webhook":
+		if cfg.WebhookURL == "" {
+			return nil, errors.New("events webhook_url is required for webhook publisher")
+		}
+		return NewWebhookPublisher(tracing.NewHTTPClient(cfg.WebhookTimeout), cfg.WebhookURL), nil
+	default:
+		return nil, fmt.Errorf("unknown events publisher %q", cfg.Publisher)
+	}
+}
+
+// Relay moves events from the outbox to the publisher. Delivery is at
+// least once and not ordered across replicas, consumers deduplicate by
+// event id.
+type Relay struct {
+	log       *slog.Logger
+	repo      OutboxRepository
+	publisher Publisher
+	cfg       config.EventsConfig
+}
+
+func NewRelay(log *slog.Logger, repo OutboxRepository, publisher Publisher, cfg config.EventsConfig) *Relay {
+	return &Relay{
+		log:       log,
+		repo:      repo,
+		publisher: publisher,
+		cfg:       cfg,
+	}
+}
+
+// Run polls the outbox every cfg.PollInterval and removes published
+// events older than cfg.Retention until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	const op = "service.outbox.Run"
+
+	log := r.log.With(slog.String("op", op))
+
+	poll := time.NewTicker(r.cfg.PollInterval)
+	defer poll.Stop()
+	purge := time.NewTicker(r.cfg.PurgeInterval)
+	defer purge.Stop()
+
+	batch := max(r.cfg.BatchSize, 1)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			// drain backlog without waiting for the next tick
+			for ctx.Err() == nil {
+				n, err := r.repo.PublishPending(ctx, batch, func(e models.Event) error {
+					return r.publisher.Publish(ctx, e)
+				})
+				if err != nil {
+					log.Error("failed to relay events", slog.String("error", err.Error()))
+					break
+				}
+				if n < batch {
+					break
+				}
+			}
+		case <-purge.C:
+			n, err := r.repo.PurgePublished(ctx, time.Now().Add(-r.cfg.Retention))
+			if err != nil {
+				log.Error("failed to purge published events", slog.String("error", err.Error()))
+				continue
+			}
+			if n > 0 {
+				log.Info("published events purged", slog.Int64("count", n))
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package preferences
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"user-service/internal/config"
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type PreferencesRepository interface {
+	Get(ctx context.Context, customerID uuid.UUID) (*models.StoredPreferences, error)
+	GetForWrite(ctx context.Context, customerID uuid.UUID) (*models.StoredPreferences, error)
+	GetMany(ctx context.Context, ids []uuid.UUID) ([]models.StoredPreferences, error)
+	Save(ctx context.Context, prefs *models.StoredPreferences) error
+}
+
+type Service struct {
+	log      *slog.Logger
+	repo     PreferencesRepository
+	cfg      config.PreferencesConfig
+	channels map[string]bool
+	quiet    *dto.QuietHoursInput
+}
+
+// New creates service, defaults in cfg are validated the same way as
+// customer input.
+func New(log *slog.Logger, repo PreferencesRepository, cfg config.PreferencesConfig) (*Service, error) {
+	const op = "service.preferences.New"
+
+	defaults := dto.UpdatePreferencesRequest{
+		Locale:               &cfg.DefaultLocale,
+		Currency:             &cfg.DefaultCurrency,
+		NotificationChannels: cfg.DefaultChannels,
+	}
+	if cfg.DefaultQuietHours != "" {
+		start, end, ok := strings.Cut(cfg.DefaultQuietHours, "-")
+		if !ok {
+			return nil, fmt.Errorf("%s: default_quiet_hours must be HH:MM-HH:MM", op)
+		}
+		defaults.QuietHours = &dto.QuietHoursInput{Start: start, End: end}
+	}
+	if err := defaults.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: invalid default: %w", op, err)
+	}
+
+	s := &Service{
+		log:      log,
+		repo:     repo,
+		channels: make(map[string]bool, len(cfg.Channels)),
+		quiet:    defaults.QuietHours,
+	}
+	for _, ch := range cfg.Channels {
+		s.channels[ch] = true
+	}
+	for _, ch := range defaults.NotificationChannels {
+		if !s.channels[ch] {
+			return nil, fmt.Errorf("%s: default channel %s is not in channels", op, ch)
+		}
+	}
+
+	cfg.DefaultLocale, cfg.DefaultCurrency = *defaults.Locale, *defaults.Currency
+	cfg.DefaultChannels = defaults.NotificationChannels
+	s.cfg = cfg
+
+	return s, nil
+}
+
+func (s *Service) GetPreferences(ctx context.Context, customerID uuid.UUID) (*models.Preferences, error) {
+	const op = "service.preferences.GetPreferences"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	stored, err := s.repo.Get(ctx, customerID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) {
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.resolve(stored), nil
+}
+
+// GetManyPreferences returns preferences by customer id, used to embed
+// them into customer lists.
+func (s *Service) GetManyPreferences(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Preferences, error) {
+	const op = "service.preferences.GetManyPreferences"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	stored, err := s.repo.GetMany(ctx, ids)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := make(map[uuid.UUID]*models.Preferences, len(stored))
+	for i := range stored {
+		res[stored[i].CustomerID] = s.resolve(&stored[i])
+	}
+	return res, nil
+}
+
+// UpdatePreferences replaces stored preferences, omitted fields return to defaults.
+func (s *Service) UpdatePreferences(ctx context.Context, customerID uuid.UUID, req *dto.UpdatePreferencesRequest) (*models.Preferences, error) {
+	const op = "service.preferences.UpdatePreferences"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+	for _, ch := range req.NotificationChannels {
+		if !s.channels[ch] {
+			return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: fmt.Errorf("unknown notification channel %s", ch)})
+		}
+	}
+
+	stored, err := s.repo.GetForWrite(ctx, customerID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) {
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stored.Locale = req.Locale
+	stored.Currency = req.Currency
+	stored.NotificationChannels = req.NotificationChannels
+	stored.QuietHoursStart, stored.QuietHoursEnd = nil, nil
+	if req.QuietHours != nil {
+		stored.QuietHoursStart, stored.QuietHoursEnd = &req.QuietHours.Start, &req.QuietHours.End
+	}
+
+	if err := s.repo.Save(ctx, stored); err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) {
+			log.ErrorContext(ctx, "failed to save preferences", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "preferences updated", slog.String("customer_id", customerID.String()))
+
+	return s.resolve(stored), nil
+}
+
+// Channels returns notification channels customers can choose from.
+func (s *Service) Channels() []string {
+	return slices.Clone(s.cfg.Channels)
+}
+
+// resolve fills unset preferences with defaults.
+func (s *Service) resolve(stored *models.StoredPreferences) *models.Preferences {
+	p := &models.Preferences{
+		CustomerID:           stored.CustomerID,
+		Locale:               s.cfg.DefaultLocale,
+		Currency:             s.cfg.DefaultCurrency,
+		NotificationChannels: slices.Clone(s.cfg.DefaultChannels),
+		UpdatedAt:            stored.UpdatedAt,
+	}
+	if stored.Locale != nil {
+		p.Locale = *stored.Locale
+	}
+	if stored.Currency != nil {
+		p.Currency = *stored.Currency
+	}
+	if stored.NotificationChannels != nil {
+		p.NotificationChannels = stored.NotificationChannels
+	}
+
+	switch {
+	case stored.QuietHoursStart != nil && stored.QuietHoursEnd != nil:
+		p.QuietHours = &models.QuietHours{Start: *stored.QuietHoursStart, End: *stored.QuietHoursEnd}
+	case stored.UpdatedAt == nil && s.quiet != nil:
+		// defaults apply only until the customer saves preferences,
+		// saving without quiet hours turns them off
+		p.QuietHours = &models.QuietHours{Start: s.quiet.Start, End: s.quiet.End}
+	}
+	if p.QuietHours != nil {
+		p.QuietHours.Timezone = stored.Timezone
+	}
+
+	return p
+}
@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"user-service/internal/config"
+)
+
+// Elector decides which replica runs the jobs.
+type Elector interface {
+	// TryAcquire takes leadership without waiting or confirms it is still
+	// held, an error means leadership is lost.
+	TryAcquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+}
+
+// Job runs every Interval on the leader, the first run right after
+// leadership is taken.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs periodic jobs on one replica at a time. Replicas campaign
+// every cfg.ElectionInterval, jobs of a replica that loses leadership are
+// cancelled. A job may still overlap with the new leader for up to an
+// election interval, jobs have to tolerate that.
+type Scheduler struct {
+	log     *slog.Logger
+	elector Elector
+	cfg     config.SchedulerConfig
+	jobs    []Job
+}
+
+func New(log *slog.Logger, elector Elector, cfg config.SchedulerConfig) *Scheduler {
+	return &Scheduler{log: log, elector: elector, cfg: cfg}
+}
+
+// Add registers job, jobs are added before Run.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Run campaigns for leadership and runs jobs while leading until ctx is
+// done, then leadership is released.
+func (s *Scheduler) Run(ctx context.Context) {
+	const op = "service.scheduler.Run"
+
+	log := s.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(s.cfg.ElectionInterval)
+	defer ticker.Stop()
+
+	// stops jobs of the current term, nil while not leading
+	var stop func()
+	defer func() {
+		if stop != nil {
+			stop()
+		}
+		releaseCtx, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		if err := s.elector.Release(releaseCtx); err != nil {
+			log.Error("failed to release leadership", slog.String("error", err.Error()))
+		}
+	}()
+
+	for {
+		electCtx, done := context.WithTimeout(ctx, s.cfg.ElectionInterval)
+		leader, err := s.elector.TryAcquire(electCtx)
+		done()
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error("leader election failed", slog.String("error", err.Error()))
+			if stop != nil {
+				log.Warn("leadership lost, stopping jobs")
+				stop()
+				stop = nil
+			}
+		case leader && stop == nil:
+			log.Info("leadership acquired, starting jobs", slog.Int("jobs", len(s.jobs)))
+			stop = s.start(ctx)
+		case !leader && stop != nil:
+			log.Warn("leadership lost, stopping jobs")
+			stop()
+			stop = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// start runs all jobs until the returned func is called, it waits for
+// running jobs to return.
+func (s *Scheduler) start(ctx context.Context) func() {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runJob(ctx, job)
+		}()
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// runJob runs job right away and then every job.Interval until ctx is done.
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	const op = "service.scheduler.runJob"
+
+	log := s.log.With(slog.String("op", op), slog.String("job", job.Name))
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Error("job failed", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package segment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type SegmentRepository interface {
+	Create(ctx context.Context, segment *models.Segment) error
+	Update(ctx context.Context, segment *models.Segment) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Segment, error)
+	List(ctx context.Context) ([]models.Segment, error)
+	Customers(ctx context.Context, rule *models.SegmentRule, after uuid.UUID, limit int) ([]models.Customer, error)
+	Count(ctx context.Context, rule *models.SegmentRule) (int64, error)
+	Check(rule *models.SegmentRule) error
+}
+
+type Service struct {
+	log  *slog.Logger
+	repo SegmentRepository
+}
+
+func New(log *slog.Logger, repo SegmentRepository) *Service {
+	return &Service{
+		log:  log,
+		repo: repo,
+	}
+}
+
+func (s *Service) CreateSegment(ctx context.Context, req *dto.SegmentRequest) (*models.Segment, error) {
+	const op = "service.segment.CreateSegment"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+	// rules that cannot run are rejected before they are saved
+	if err := s.repo.Check(req.Rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	segment := &models.Segment{ID: uuid.New(), Name: req.Name, Rules: *req.Rules}
+	if err := s.repo.Create(ctx, segment); err != nil {
+		if !errors.Is(err, storage.ErrSegmentExists) {
+			log.ErrorContext(ctx, "failed to create segment", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "segment created", slog.String("segment_id", segment.ID.String()))
+
+	return segment, nil
+}
+
+func (s *Service) UpdateSegment(ctx context.Context, id uuid.UUID, req *dto.SegmentRequest) (*models.Segment, error) {
+	const op = "service.segment.UpdateSegment"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+	// rules that cannot run are rejected before they are saved
+	if err := s.repo.Check(req.Rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	segment := &models.Segment{ID: id, Name: req.Name, Rules: *req.Rules}
+	if err := s.repo.Update(ctx, segment); err != nil {
+		if !errors.Is(err, storage.ErrSegmentNotFound) && !errors.Is(err, storage.ErrSegmentExists) {
+			log.ErrorContext(ctx, "failed to update segment", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "segment updated", slog.String("segment_id", id.String()))
+
+	return segment, nil
+}
+
+func (s *Service) DeleteSegment(ctx context.Context, id uuid.UUID) error {
+	const op = "service.segment.DeleteSegment"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if !errors.Is(err, storage.ErrSegmentNotFound) {
+			log.ErrorContext(ctx, "failed to delete segment", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "segment deleted", slog.String("segment_id", id.String()))
+
+	return nil
+}
+
+func (s *Service) GetSegment(ctx context.Context, id uuid.UUID) (*models.Segment, error) {
+	const op = "service.segment.GetSegment"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	segment, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrSegmentNotFound) {
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return segment, nil
+}
+
+func (s *Service) ListSegments(ctx context.Context) ([]models.Segment, error) {
+	const op = "service.segment.ListSegments"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	segments, err := s.repo.List(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return segments, nil
+}
+
+// SegmentCustomers returns a page of customers currently in segment,
+// ordered by id and starting after the given one.
+func (s *Service) SegmentCustomers(ctx context.Context, id, after uuid.UUID, limit int) ([]models.Customer, error) {
+	const op = "service.segment.SegmentCustomers"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	segment, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrSegmentNotFound) {
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	customers, err := s.repo.Customers(ctx, &segment.Rules, after, limit)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUnsupportedQuery) {
+			log.ErrorContext(ctx, "failed to get segment customers", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return customers, nil
+}
+
+// PreviewSegment counts customers matching rules without saving them.
+func (s *Service) PreviewSegment(ctx context.Context, req *dto.PreviewSegmentRequest) (int64, error) {
+	const op = "service.segment.PreviewSegment"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := req.Validate(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+
+	count, err := s.repo.Count(ctx, req.Rules)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUnsupportedQuery) {
+			log.ErrorContext(ctx, "failed to count segment", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,105 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/service"
+	"user-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type TagsRepository interface {
+	List(ctx context.Context, customerID uuid.UUID) ([]string, error)
+	Add(ctx context.Context, customerID uuid.UUID, tags []string) ([]string, error)
+	Remove(ctx context.Context, customerID uuid.UUID, tag string) ([]string, error)
+}
+
+type Service struct {
+	log  *slog.Logger
+	repo TagsRepository
+}
+
+func New(log *slog.Logger, repo TagsRepository) *Service {
+	return &Service{
+		log:  log,
+		repo: repo,
+	}
+}
+
+func (s *Service) GetTags(ctx context.Context, customerID uuid.UUID) ([]string, error) {
+	const op = "service.tags.GetTags"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tags, err := s.repo.List(ctx, customerID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) {
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+// AddTags adds tags to customer and returns all its tags.
+func (s *Service) AddTags(ctx context.Context, customerID uuid.UUID, req *dto.TagsRequest) ([]string, error) {
+	const op = "service.tags.AddTags"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+
+	tags, err := s.repo.Add(ctx, customerID, req.Tags)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) && !errors.Is(err, storage.ErrCustomerErased) {
+			log.ErrorContext(ctx, "failed to add tags", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "tags added", slog.String("customer_id", customerID.String()), slog.Int("count", len(req.Tags)))
+
+	return tags, nil
+}
+
+// RemoveTag removes tag from customer and returns remaining tags.
+func (s *Service) RemoveTag(ctx context.Context, customerID uuid.UUID, tag string) ([]string, error) {
+	const op = "service.tags.RemoveTag"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(slog.String("op", op))
+
+	tag, err := dto.NormalizeTag(tag)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, &service.ValidationError{Err: err})
+	}
+
+	tags, err := s.repo.Remove(ctx, customerID, tag)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) && !errors.Is(err, storage.ErrCustomerErased) {
+			log.ErrorContext(ctx, "failed to remove tag", slog.String("error", err.Error()))
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "tag removed", slog.String("customer_id", customerID.String()), slog.String("tag", tag))
+
+	return tags, nil
+}
@@ -0,0 +1,329 @@
+// Package pii maps customers to and from rows with encrypted PII columns.
+package pii
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"user-service/internal/domain/models"
+	birthdays "user-service/internal/lib/birthday"
+	"user-service/internal/lib/envelope"
+
+	"github.com/google/uuid"
+)
+
+// Columns that can be encrypted.
+const (
+	FirstName = "first_name"
+	LastName  = "last_name"
+	Birthday  = "birthday"
+)
+
+var ErrEncryptionDisabled = errors.New("encryption is disabled")
+
+// SelectColumns customer columns read into Row.
+const SelectColumns = `id, first_name, last_name, gender, timezone, birthday, user_id, created_at, erased_at, attributes,
+            first_name_enc, last_name_enc, birthday_enc, data_key_id`
+
+// InsertColumns customer columns in Row.Values order.
+var InsertColumns = []string{
+	"id", "first_name", "last_name", "gender", "timezone", "birthday", "user_id", "attributes",
+	"first_name_enc", "last_name_enc", "birthday_enc",
+	"first_name_bidx", "last_name_bidx", "birthday_bidx", "data_key_id", "birthday_md",
+}
+
+// Row customer as stored. Plaintext PII fields shadow the embedded
+// customer ones and are NULL when the column is encrypted.
+type Row struct {
+	models.Customer
+	FirstName    *string    `db:"first_name"`
+	LastName     *string    `db:"last_name"`
+	Birthday     *time.Time `db:"birthday"`
+	FirstNameEnc []byte     `db:"first_name_enc"`
+	LastNameEnc  []byte     `db:"last_name_enc"`
+	BirthdayEnc  []byte     `db:"birthday_enc"`
+	FirstNameIdx []byte     `db:"first_name_bidx"`
+	LastNameIdx  []byte     `db:"last_name_bidx"`
+	BirthdayIdx  []byte     `db:"birthday_bidx"`
+	DataKeyID    *int64     `db:"data_key_id"`
+	// BirthdayMD month and day of birthday as MMDD for birthday queries,
+	// NULL when birthday is encrypted unless the codec keeps it.
+	BirthdayMD *int16 `db:"birthday_md"`
+}
+
+// Values returns column values in InsertColumns order.
+func (r *Row) Values() []any {
+	return append([]any{r.ID, r.FirstName, r.LastName, r.Gender, r.Timezone, r.Birthday, r.UserID, r.Attributes},
+		r.EncryptionValues()...)
+}
+
+// EncryptionValues returns values of first_name_enc through birthday_md.
+// Missing bytea values are passed as untyped nil, the driver sends nil
+// []byte as an empty value rather than NULL.
+func (r *Row) EncryptionValues() []any {
+	return []any{
+		nullBytes(r.FirstNameEnc), nullBytes(r.LastNameEnc), nullBytes(r.BirthdayEnc),
+		nullBytes(r.FirstNameIdx), nullBytes(r.LastNameIdx), nullBytes(r.BirthdayIdx), r.DataKeyID,
+		r.BirthdayMD,
+	}
+}
+
+func nullBytes(b []byte) any {
+	if b == nil {
+		return nil
+	}
+	return b
+}
+
+// Codec encrypts configured columns on write and decrypts any encrypted
+// column on read, so rows written before a config change stay readable.
+// Codec with nil cipher stores plaintext. Month and day of an encrypted
+// birthday are only written when monthDay is set.
+type Codec struct {
+	cipher   *envelope.Cipher
+	columns  map[string]bool
+	monthDay bool
+}
+
+func NewCodec(cipher *envelope.Cipher, columns []string, monthDay bool) (*Codec, error) {
+	c := &Codec{cipher: cipher, columns: make(map[string]bool), monthDay: monthDay}
+	for _, col := range columns {
+		switch col {
+		case FirstName, LastName, Birthday:
+			c.columns[col] = true
+		default:
+			return nil, fmt.Errorf("pii.NewCodec: column %q cannot be encrypted", col)
+		}
+	}
+	return c, nil
+}
+
+// Enabled reports whether writes are encrypted.
+func (c *Codec) Enabled() bool {
+	return c.cipher != nil && len(c.columns) > 0
+}
+
+// Encrypted reports whether column is written encrypted, lookups on it
+// must go through the blind index.
+func (c *Codec) Encrypted(column string) bool {
+	return c.Enabled() && c.columns[column]
+}
+
+// MonthDayKept reports whether birthday_md is written.
+func (c *Codec) MonthDayKept() bool {
+	return c.monthDay || !c.Encrypted(Birthday)
+}
+
+// Index returns blind index of value for lookups on encrypted column.
+// Names are matched case-insensitively.
+func (c *Codec) Index(column, value string) []byte {
+	return c.cipher.BlindIndex(column, normalize(column, value))
+}
+
+// ActiveKeyID returns id of the data key used for new writes, 0 when
+// encryption is disabled.
+func (c *Codec) ActiveKeyID(ctx context.Context) (int64, error) {
+	if !c.Enabled() {
+		return 0, nil
+	}
+	key, err := c.cipher.Active(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("pii.ActiveKeyID: %w", err)
+	}
+	return key.ID, nil
+}
+
+// Rotate creates a new data key and makes it active for this process,
+// other processes pick it up within the cipher refresh interval.
+func (c *Codec) Rotate(ctx context.Context) (int64, error) {
+	if !c.Enabled() {
+		return 0, fmt.Errorf("pii.Rotate: %w", ErrEncryptionDisabled)
+	}
+	key, err := c.cipher.Rotate(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("pii.Rotate: %w", err)
+	}
+	return key.ID, nil
+}
+
+// Seal converts customer into row, encrypting configured columns with
+// the active data key.
+func (c *Codec) Seal(ctx context.Context, customer *models.Customer) (*Row, error) {
+	const op = "pii.Seal"
+
+	firstName, lastName, birthday := customer.FirstName, customer.LastName, customer.Birthday
+	row := &Row{
+		Customer:  *customer,
+		FirstName: &firstName,
+		LastName:  &lastName,
+		Birthday:  &birthday,
+	}
+	if c.MonthDayKept() {
+		md := birthdays.MonthDay(birthday)
+		row.BirthdayMD = &md
+	}
+	if !c.Enabled() {
+		return row, nil
+	}
+
+	key, err := c.cipher.Active(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	row.DataKeyID = &key.ID
+
+	if c.columns[FirstName] {
+		if row.FirstNameEnc, row.FirstNameIdx, err = c.seal(key, customer.ID, FirstName, firstName); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		row.FirstName = nil
+	}
+	if c.columns[LastName] {
+		if row.LastNameEnc, row.LastNameIdx, err = c.seal(key, customer.ID, LastName, lastName); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		row.LastName = nil
+	}
+	if c.columns[Birthday] {
+		if row.BirthdayEnc, row.BirthdayIdx, err = c.seal(key, customer.ID, Birthday, birthday.Format(time.DateOnly)); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		row.Birthday = nil
+	}
+
+	return row, nil
+}
+
+// Open decrypts row in place and returns the embedded customer.
+func (c *Codec) Open(ctx context.Context, row *Row) (*models.Customer, error) {
+	const op = "pii.Open"
+
+	cust := &row.Customer
+	cust.FirstName, cust.LastName, cust.Birthday = "", "", time.Time{}
+	if row.FirstName != nil {
+		cust.FirstName = *row.FirstName
+	}
+	if row.LastName != nil {
+		cust.LastName = *row.LastName
+	}
+	if row.Birthday != nil {
+		cust.Birthday = *row.Birthday
+	}
+	if row.DataKeyID == nil {
+		return cust, nil
+	}
+
+	key, err := c.key(ctx, *row.DataKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if row.FirstNameEnc != nil {
+		if cust.FirstName, err = c.open(key, cust.ID, FirstName, row.FirstNameEnc); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if row.LastNameEnc != nil {
+		if cust.LastName, err = c.open(key, cust.ID, LastName, row.LastNameEnc); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if row.BirthdayEnc != nil {
+		v, err := c.open(key, cust.ID, Birthday, row.BirthdayEnc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if cust.Birthday, err = time.Parse(time.DateOnly, v); err != nil {
+			return nil, fmt.Errorf("%s: birthday: %w", op, err)
+		}
+	}
+
+	return cust, nil
+}
+
+// OpenJSON decrypts a customer row serialized with to_jsonb, as kept in
+// change history. Encryption columns are removed from the result.
+func (c *Codec) OpenJSON(ctx context.Context, customerID uuid.UUID, data json.RawMessage) (json.RawMessage, error) {
+	const op = "pii.OpenJSON"
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var row map[string]any
+	if err := dec.Decode(&row); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if id, ok := row["data_key_id"].(json.Number); ok {
+		keyID, err := id.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		key, err := c.key(ctx, keyID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		for _, col := range []string{FirstName, LastName, Birthday} {
+			// bytea is serialized as "\x<hex>"
+			s, ok := row[col+"_enc"].(string)
+			if !ok {
+				continue
+			}
+			ciphertext, err := hex.DecodeString(strings.TrimPrefix(s, `\x`))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", op, col, err)
+			}
+			if row[col], err = c.open(key, customerID, col, ciphertext); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	for _, col := range []string{FirstName, LastName, Birthday} {
+		delete(row, col+"_enc")
+		delete(row, col+"_bidx")
+	}
+	delete(row, "data_key_id")
+
+	return json.Marshal(row)
+}
+
+func (c *Codec) key(ctx context.Context, id int64) (*envelope.Key, error) {
+	if c.cipher == nil {
+		return nil, ErrEncryptionDisabled
+	}
+	return c.cipher.Key(ctx, id)
+}
+
+func (c *Codec) seal(key *envelope.Key, id uuid.UUID, column, value string) (ciphertext, index []byte, err error) {
+	ciphertext, err = key.Seal([]byte(value), aad(id, column))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", column, err)
+	}
+	return ciphertext, c.Index(column, value), nil
+}
+
+func (c *Codec) open(key *envelope.Key, id uuid.UUID, column string, ciphertext []byte) (string, error) {
+	plaintext, err := key.Open(ciphertext, aad(id, column))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", column, err)
+	}
+	return string(plaintext), nil
+}
+
+// aad binds ciphertext to customer and column.
+func aad(id uuid.UUID, column string) []byte {
+	return append(id[:], column...)
+}
+
+func normalize(column, value string) string {
+	if column == Birthday {
+		return value
+	}
+	return strings.ToLower(strings.TrimSpace(value))
+}
@@ -0,0 +1,42 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"user-service/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+// query_canceled: statement_timeout or cancel request
+const pqQueryCanceled = "57014"
+
+// ClassifyError wraps driver errors into typed storage errors:
+// storage.ErrQueryTimeout for cancelled/timed out statements and
+// storage.ErrUnavailable for broken connections.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", storage.ErrQueryTimeout, err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqQueryCanceled {
+		return fmt.Errorf("%w: %w", storage.ErrQueryTimeout, err)
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr) {
+		return fmt.Errorf("%w: %w", storage.ErrUnavailable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,86 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+)
+
+// AdvisoryLock session level Postgres advisory lock. The lock lives as
+// long as the connection it was taken on, that connection is kept out
+// of the pool while the lock is held. Does not work behind a
+// transaction pooling proxy.
+type AdvisoryLock struct {
+	storage *Storage
+	key     int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewAdvisoryLock returns lock on key, nothing is taken until TryAcquire.
+func (s *Storage) NewAdvisoryLock(key int64) *AdvisoryLock {
+	return &AdvisoryLock{storage: s, key: key}
+}
+
+// TryAcquire takes the lock without waiting and reports whether it is
+// held. When already held the connection is checked instead, an error
+// means the session and with it the lock may be gone.
+func (l *AdvisoryLock) TryAcquire(ctx context.Context) (bool, error) {
+	const op = "storage.psql.AdvisoryLock.TryAcquire"
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err != nil {
+			l.conn.Close()
+			l.conn = nil
+			return false, fmt.Errorf("%s: lock connection lost: %w", op, err)
+		}
+		return true, nil
+	}
+
+	conn, err := l.storage.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, ClassifyError(err))
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("%s: %w", op, ClassifyError(err))
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Release unlocks and returns the connection to the pool. Closing the
+// connection releases the lock as well, so it is closed if unlock fails.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	const op = "storage.psql.AdvisoryLock.Release"
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+	conn := l.conn
+	l.conn = nil
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		// a connection still holding the lock must not go back to the pool
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+		conn.Close()
+		return fmt.Errorf("%s: %w", op, ClassifyError(err))
+	}
+	return conn.Close()
+}
@@ -1,43 +1,101 @@
 package psql
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"runtime/debug"
+	"sync/atomic"
+	"time"
 
 	"user-service/internal/config"
+	"user-service/internal/lib/retry"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
 type Storage struct {
-	db *sqlx.DB
+	db       *sqlx.DB
+	replicas []*replica
+	next     atomic.Uint64
+	log      *slog.Logger
 }
 
-// Init connection to database
-func Init(cfg config.PostgresConfig) *Storage {
+// Init connection to database. Connection is retried according to cfg.ConnectRetry.
+func Init(ctx context.Context, cfg config.PostgresConfig, log *slog.Logger) (*Storage, error) {
 	const op = "storage.psql.Init"
 
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DbName, cfg.SslMode)
+	connStr := connString(cfg, cfg.Host, cfg.Port)
 
-	db, err := sqlx.Connect("postgres", connStr)
+	var db *sqlx.DB
+	err := retry.Do(ctx, cfg.ConnectRetry, log, "postgres", func(ctx context.Context) error {
+		var err error
+		db, err = sqlx.ConnectContext(ctx, "postgres", connStr)
+		return err
+	})
 	if err != nil {
-		panic(fmt.Sprintf("%s: failed to open db: %v", op, err))
+		return nil, fmt.Errorf("%s: failed to connect to db: %w", op, err)
 	}
 
-	if err = db.Ping(); err != nil {
-		panic(fmt.Sprintf("%s: failed to ping db: %v", op, err))
+	applyPool(db, cfg)
+
+	s := &Storage{db: db, log: log}
+
+	for _, rc := range cfg.Replicas {
+		// Replicas are opened lazily and never block startup,
+		// health loop puts them into rotation once reachable.
+		rdb, err := sqlx.Open("postgres", connString(cfg, rc.Host, rc.Port))
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: failed to open replica %s:%d: %w", op, rc.Host, rc.Port, err)
+		}
+		applyPool(rdb, cfg)
+		s.replicas = append(s.replicas, &replica{
+			name: fmt.Sprintf("%s:%d", rc.Host, rc.Port),
+			db:   rdb,
+		})
 	}
 
-	return &Storage{db: db}
+	return s, nil
+}
+
+func connString(cfg config.PostgresConfig, host string, port int) string {
+	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		host, port, cfg.User, cfg.Password, cfg.DbName, cfg.SslMode)
+	if cfg.StatementTimeout > 0 {
+		// lib/pq passes unknown keys as session runtime parameters
+		connStr += fmt.Sprintf(" statement_timeout=%d", cfg.StatementTimeout.Milliseconds())
+	}
+	return connStr
 }
 
+func applyPool(db *sqlx.DB, cfg config.PostgresConfig) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
+// WithTimeout bounds a single statement with timeout, zero means none.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// GetDB returns primary database.
 func (s *Storage) GetDB() *sqlx.DB {
 	return s.db
 }
 
+// Ping checks database is reachable.
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
 // Close connection
 func (s *Storage) Close() {
 	if s.db != nil {
@@ -45,4 +103,7 @@ func (s *Storage) Close() {
 		log.Printf("DB stats before close: InUse=some Idle=some")
 		s.db.Close()
 	}
+	for _, r := range s.replicas {
+		r.db.Close()
+	}
 }
@@ -0,0 +1,108 @@
+package psql
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type replica struct {
+	name    string
+	db      *sqlx.DB
+	healthy atomic.Bool
+}
+
+type consistencyKey struct{}
+
+// consistency is a mutable per-request marker, set once the request writes.
+type consistency struct {
+	written atomic.Bool
+}
+
+// WithConsistency installs read-your-writes marker into ctx. After any write
+// made with this ctx, reads are routed to primary for the rest of the request.
+func WithConsistency(ctx context.Context) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, &consistency{})
+}
+
+// MarkWritten flags ctx as having written to primary.
+func MarkWritten(ctx context.Context) {
+	if c, ok := ctx.Value(consistencyKey{}).(*consistency); ok {
+		c.written.Store(true)
+	}
+}
+
+func hasWritten(ctx context.Context) bool {
+	c, ok := ctx.Value(consistencyKey{}).(*consistency)
+	return ok && c.written.Load()
+}
+
+// Reader returns database for read queries: a healthy replica in round-robin
+// order, or primary if there are none or ctx has already written.
+func (s *Storage) Reader(ctx context.Context) *sqlx.DB {
+	if len(s.replicas) == 0 || hasWritten(ctx) {
+		return s.db
+	}
+
+	start := s.next.Add(1)
+	for i := range s.replicas {
+		r := s.replicas[(int(start)+i)%len(s.replicas)]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+
+	return s.db
+}
+
+// Writer returns primary database and marks ctx for read-your-writes.
+func (s *Storage) Writer(ctx context.Context) *sqlx.DB {
+	MarkWritten(ctx)
+	return s.db
+}
+
+// RunReplicaHealthCheck pings replicas every interval, removing failed
+// ones from rotation, until ctx is done.
+func (s *Storage) RunReplicaHealthCheck(ctx context.Context, interval time.Duration) {
+	const op = "storage.psql.RunReplicaHealthCheck"
+
+	if len(s.replicas) == 0 {
+		return
+	}
+
+	log := s.log.With(slog.String("op", op))
+
+	check := func() {
+		for _, r := range s.replicas {
+			pingCtx, cancel := context.WithTimeout(ctx, interval)
+			err := r.db.PingContext(pingCtx)
+			cancel()
+
+			healthy := err == nil
+			if r.healthy.Swap(healthy) != healthy {
+				if healthy {
+					log.Info("replica is back in rotation", slog.String("replica", r.name))
+				} else {
+					log.Warn("replica removed from rotation", slog.String("replica", r.name), slog.String("error", err.Error()))
+				}
+			}
+		}
+	}
+
+	check()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
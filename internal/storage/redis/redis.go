@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"user-service/internal/config"
+	"user-service/internal/lib/retry"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Storage struct {
+	client *redis.Client
+}
+
+// Init connection to redis. Ping is retried according to cfg.ConnectRetry.
+func Init(ctx context.Context, cfg config.RedisConfig, log *slog.Logger) (*Storage, error) {
+	const op = "storage.redis.Init"
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	err := retry.Do(ctx, cfg.ConnectRetry, log, "redis", func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("%s: failed to ping redis: %w", op, err)
+	}
+
+	return &Storage{client: client}, nil
+}
+
+func (s *Storage) GetClient() *redis.Client {
+	return s.client
+}
+
+// Ping checks redis is reachable.
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Close connection
+func (s *Storage) Close() error {
+	return s.client.Close()
+}
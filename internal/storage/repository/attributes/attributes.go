@@ -0,0 +1,109 @@
+package attributes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage"
+	"user-service/internal/storage/psql"
+)
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout}
+}
+
+// List returns all registered schemas ordered by namespace. Schemas are
+// read from primary so a write right after registration sees them.
+func (r *Repository) List(ctx context.Context) ([]models.AttributeSchema, error) {
+	const op = "repository.attributes.List"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT namespace, schema, version, created_at, updated_at
+        FROM attribute_schemas
+        ORDER BY namespace
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	schemas := []models.AttributeSchema{}
+	if err := r.storage.Writer(ctx).SelectContext(ctx, &schemas, query); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return schemas, nil
+}
+
+func (r *Repository) Get(ctx context.Context, namespace string) (*models.AttributeSchema, error) {
+	const op = "repository.attributes.Get"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT namespace, schema, version, created_at, updated_at
+        FROM attribute_schemas
+        WHERE namespace = $1
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var schema models.AttributeSchema
+	if err := r.storage.Reader(ctx).GetContext(ctx, &schema, query, namespace); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrAttributeSchemaNotFound
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return &schema, nil
+}
+
+// Save registers schema of namespace or replaces it, bumping the version.
+func (r *Repository) Save(ctx context.Context, namespace string, schema []byte) (*models.AttributeSchema, error) {
+	const op = "repository.attributes.Save"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        INSERT INTO attribute_schemas (namespace, schema)
+        VALUES ($1, $2)
+        ON CONFLICT (namespace) DO UPDATE
+        SET schema = EXCLUDED.schema,
+            version = attribute_schemas.version + 1,
+            updated_at = CURRENT_TIMESTAMP
+        RETURNING namespace, schema, version, created_at, updated_at
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var saved models.AttributeSchema
+	if err := r.storage.Writer(ctx).GetContext(ctx, &saved, query, namespace, string(schema)); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return &saved, nil
+}
@@ -0,0 +1,228 @@
+package consent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage"
+	"user-service/internal/storage/psql"
+	"user-service/internal/storage/repository/outbox"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const currentQuery = `
+        SELECT DISTINCT ON (purpose) id, customer_id, purpose, status, source, legal_basis_version, recorded_at
+        FROM consents
+        WHERE customer_id = $1
+        ORDER BY purpose, id DESC
+    `
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout}
+}
+
+// Record appends records that change status or legal basis version of
+// their purpose and queues EventConsentChanged for each, in one
+// transaction. Records equal to the current state are skipped, so
+// repeating a request changes nothing. Returns the current consents.
+func (r *Repository) Record(ctx context.Context, customerID uuid.UUID, records []models.Consent) ([]models.Consent, error) {
+	const op = "repository.consent.Record"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	const (
+		// serializes concurrent updates of the same customer
+		lockQuery   = `SELECT erased_at FROM customers WHERE id = $1 FOR UPDATE`
+		insertQuery = `
+            INSERT INTO consents (customer_id, purpose, status, source, legal_basis_version)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id, recorded_at
+        `
+	)
+
+	ctx, span := tracing.StartDB(ctx, op, insertQuery)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.storage.Writer(ctx).BeginTxx(ctx, nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer tx.Rollback()
+
+	var erasedAt *time.Time
+	if err := tx.QueryRowxContext(ctx, lockQuery, customerID).Scan(&erasedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrUserNotFound
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	if erasedAt != nil {
+		return nil, storage.ErrCustomerErased
+	}
+
+	var current []models.Consent
+	if err := tx.SelectContext(ctx, &current, currentQuery, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	byPurpose := make(map[string]models.Consent, len(current))
+	for _, c := range current {
+		byPurpose[c.Purpose] = c
+	}
+
+	for _, rec := range records {
+		prev, ok := byPurpose[rec.Purpose]
+		if ok && prev.Status == rec.Status && prev.LegalBasisVersion == rec.LegalBasisVersion {
+			continue
+		}
+
+		rec.CustomerID = customerID
+		err := tx.QueryRowxContext(ctx, insertQuery,
+			customerID, rec.Purpose, rec.Status, rec.Source, rec.LegalBasisVersion,
+		).Scan(&rec.ID, &rec.RecordedAt)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+
+		if err := insertChangedEvent(ctx, tx, rec, prev.Status); err != nil {
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		byPurpose[rec.Purpose] = rec
+	}
+
+	if err := tx.Commit(); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	res := make([]models.Consent, 0, len(byPurpose))
+	for _, c := range byPurpose {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Purpose < res[j].Purpose })
+
+	return res, nil
+}
+
+func insertChangedEvent(ctx context.Context, tx sqlx.ExecerContext, rec models.Consent, prevStatus string) error {
+	event, err := models.NewEvent(models.EventConsentChanged, rec.CustomerID, models.ConsentChanged{
+		CustomerID:        rec.CustomerID,
+		Purpose:           rec.Purpose,
+		Status:            rec.Status,
+		PreviousStatus:    prevStatus,
+		Source:            rec.Source,
+		LegalBasisVersion: rec.LegalBasisVersion,
+		RecordedAt:        rec.RecordedAt,
+	})
+	if err != nil {
+		return err
+	}
+	return outbox.Insert(ctx, tx, event)
+}
+
+// Current returns the latest record of every purpose, ordered by purpose.
+func (r *Repository) Current(ctx context.Context, customerID uuid.UUID) ([]models.Consent, error) {
+	const op = "repository.consent.Current"
+	return r.selectForCustomer(ctx, op, currentQuery, customerID)
+}
+
+// History returns all records of customer in the order they were made.
+func (r *Repository) History(ctx context.Context, customerID uuid.UUID) ([]models.Consent, error) {
+	const op = "repository.consent.History"
+
+	query := `
+        SELECT id, customer_id, purpose, status, source, legal_basis_version, recorded_at
+        FROM consents
+        WHERE customer_id = $1
+        ORDER BY id
+    `
+
+	return r.selectForCustomer(ctx, op, query, customerID)
+}
+
+// selectForCustomer runs query for customer, returns storage.ErrUserNotFound
+// if customer does not exist.
+func (r *Repository) selectForCustomer(ctx context.Context, op, query string, customerID uuid.UUID) ([]models.Consent, error) {
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	db := r.storage.Reader(ctx)
+
+	var exists bool
+	if err := db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1)`, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	if !exists {
+		return nil, storage.ErrUserNotFound
+	}
+
+	consents := []models.Consent{}
+	if err := db.SelectContext(ctx, &consents, query, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return consents, nil
+}
+
+// ListGranted returns current granted records of purpose for customers
+// with id greater than after, ordered by customer id. Erased customers
+// are left out.
+func (r *Repository) ListGranted(ctx context.Context, purpose string, after uuid.UUID, limit int) ([]models.Consent, error) {
+	const op = "repository.consent.ListGranted"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT t.id, t.customer_id, t.purpose, t.status, t.source, t.legal_basis_version, t.recorded_at
+        FROM (
+            SELECT DISTINCT ON (customer_id) id, customer_id, purpose, status, source, legal_basis_version, recorded_at
+            FROM consents
+            WHERE purpose = $1 AND customer_id > $2
+            ORDER BY customer_id, id DESC
+        ) t
+        JOIN customers c ON c.id = t.customer_id AND c.erased_at IS NULL
+        WHERE t.status = 'granted'
+        ORDER BY t.customer_id
+        LIMIT $3
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	consents := []models.Consent{}
+	if err := r.storage.Reader(ctx).SelectContext(ctx, &consents, query, purpose, after, limit); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return consents, nil
+}
@@ -3,135 +3,349 @@ package customer
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"user-service/internal/domain/dto"
 	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
 	"user-service/internal/storage"
+	"user-service/internal/storage/pii"
+	"user-service/internal/storage/psql"
+	"user-service/internal/storage/repository/outbox"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
-	db *sqlx.DB
+	storage      *psql.Storage
+	queryTimeout time.Duration
+	codec        *pii.Codec
 }
 
-func New(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+// New creates repository. Reads go to replicas, writes to primary.
+// Every call is bounded by queryTimeout unless the incoming context
+// has an earlier deadline. PII columns are encrypted and decrypted by codec.
+func New(storage *psql.Storage, queryTimeout time.Duration, codec *pii.Codec) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout, codec: codec}
 }
 
 func (r *Repository) Create(ctx context.Context, customer *models.Customer) error {
 	const op = "repository.customer.Create"
+	defer metrics.ObserveQuery(op, time.Now())
 
 	query := `
-        INSERT INTO customers (id, first_name, last_name, gender, timezone, birthday, user_id)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        INSERT INTO customers (id, first_name, last_name, gender, timezone, birthday, user_id, attributes,
+            first_name_enc, last_name_enc, birthday_enc,
+            first_name_bidx, last_name_bidx, birthday_bidx, data_key_id, birthday_md)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
     `
 
-	_, err := r.db.ExecContext(ctx, query,
-		customer.ID,
-		customer.FirstName,
-		customer.LastName,
-		customer.Gender,
-		customer.Timezone,
-		customer.Birthday,
-		customer.UserID,
-	)
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
 
+	row, err := r.codec.Seal(ctx, customer)
 	if err != nil {
+		tracing.RecordError(span, err)
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	_, err = r.storage.Writer(ctx).ExecContext(ctx, query, row.Values()...)
+
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
 	return nil
 }
 
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
 	const op = "repository.customer.GetByID"
+	return r.getByID(ctx, op, id, r.storage.Reader)
+}
+
+// GetByIDForWrite is GetByID read from primary. Read-modify-write paths
+// use it, a lagging replica would have them overwrite newer data.
+func (r *Repository) GetByIDForWrite(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+	const op = "repository.customer.GetByIDForWrite"
+	return r.getByID(ctx, op, id, r.storage.Writer)
+}
+
+func (r *Repository) getByID(ctx context.Context, op string, id uuid.UUID, db func(context.Context) *sqlx.DB) (*models.Customer, error) {
+	defer metrics.ObserveQuery(op, time.Now())
 
 	query := `
-        SELECT id, first_name, last_name, gender, timezone, birthday, user_id, created_at
+        SELECT ` + pii.SelectColumns + `
         FROM customers
         WHERE id = $1
     `
 
-	var customer models.Customer
-	err := r.db.GetContext(ctx, &customer, query, id)
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var row pii.Row
+	err := db(ctx).GetContext(ctx, &row, query, id)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, storage.ErrUserNotFound
 		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	customer, err := r.codec.Open(ctx, &row)
+	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &customer, nil
+	return customer, nil
 }
 
 func (r *Repository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Customer, error) {
 	const op = "repository.customer.GetByUserID"
+	defer metrics.ObserveQuery(op, time.Now())
 
 	query := `
-        SELECT id, first_name, last_name, gender, timezone, birthday, user_id, created_at
+        SELECT ` + pii.SelectColumns + `
         FROM customers
         WHERE user_id = $1
     `
 
-	var customer models.Customer
-	err := r.db.GetContext(ctx, &customer, query, userID)
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var row pii.Row
+	err := r.storage.Reader(ctx).GetContext(ctx, &row, query, userID)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, storage.ErrUserNotFound
 		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	customer, err := r.codec.Open(ctx, &row)
+	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &customer, nil
+	return customer, nil
 }
-func (r *Repository) GetAll(ctx context.Context) ([]models.Customer, error) {
+func (r *Repository) GetAll(ctx context.Context, filter dto.CustomerFilter) ([]models.Customer, error) {
 	const op = "repository.customer.GetAll"
+	defer metrics.ObserveQuery(op, time.Now())
 
+	where, args := r.filterClause(filter)
 	query := `
-        SELECT id, first_name, last_name, gender, timezone, birthday, user_id, created_at
+        SELECT ` + pii.SelectColumns + `
         FROM customers
+        ` + where + `
         ORDER BY created_at DESC
     `
 
-	var customers []models.Customer
-	err := r.db.SelectContext(ctx, &customers, query)
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var rows []pii.Row
+	err := r.storage.Reader(ctx).SelectContext(ctx, &rows, query, args...)
 
 	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	customers, err := r.openAll(ctx, rows)
+	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return customers, nil
 }
+
+// Stream calls fn for every customer matching filter, rows are read
+// from an open cursor one by one. The customer passed to fn is reused
+// between calls. Query timeout does not apply, the stream lasts until
+// all rows are consumed or ctx is cancelled.
+func (r *Repository) Stream(ctx context.Context, filter dto.CustomerFilter, fn func(*models.Customer) error) error {
+	const op = "repository.customer.Stream"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	where, args := r.filterClause(filter)
+	query := `
+        SELECT ` + pii.SelectColumns + `
+        FROM customers
+        ` + where + `
+        ORDER BY created_at DESC
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	tx, err := r.storage.Reader(ctx).BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer tx.Rollback()
+
+	// session statement_timeout would abort long exports
+	if _, err := tx.ExecContext(ctx, `SET LOCAL statement_timeout = 0`); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer rows.Close()
+
+	var row pii.Row
+	for rows.Next() {
+		if err := rows.StructScan(&row); err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		customer, err := r.codec.Open(ctx, &row)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := fn(customer); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// filterClause builds WHERE clause with positional args for filter,
+// erased customers never match. Encrypted columns are matched by blind
+// index, exact match only.
+func (r *Repository) filterClause(filter dto.CustomerFilter) (string, []any) {
+	var (
+		conds = []string{"erased_at IS NULL"}
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, strings.ReplaceAll(cond, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	name := func(column, value string) string {
+		if r.codec.Encrypted(column) {
+			args = append(args, r.codec.Index(column, value))
+			return fmt.Sprintf("%s_bidx = $%d", column, len(args))
+		}
+		args = append(args, strings.TrimSpace(value))
+		return fmt.Sprintf("lower(%s) = lower($%d)", column, len(args))
+	}
+
+	if filter.Gender != "" {
+		add("gender = $?", filter.Gender)
+	}
+	if filter.Timezone != "" {
+		add("timezone = $?", filter.Timezone)
+	}
+	if filter.CreatedAfter != nil {
+		add("created_at >= $?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		add("created_at < $?", *filter.CreatedBefore)
+	}
+	if filter.FirstName != "" {
+		conds = append(conds, name(pii.FirstName, filter.FirstName))
+	}
+	if filter.LastName != "" {
+		conds = append(conds, name(pii.LastName, filter.LastName))
+	}
+	if filter.Search != "" {
+		conds = append(conds, "("+name(pii.FirstName, filter.Search)+" OR "+name(pii.LastName, filter.Search)+")")
+	}
+	if filter.Birthday != nil {
+		if r.codec.Encrypted(pii.Birthday) {
+			add("birthday_bidx = $?", r.codec.Index(pii.Birthday, filter.Birthday.Format(time.DateOnly)))
+		} else {
+			add("birthday = $?", filter.Birthday.Format(time.DateOnly))
+		}
+	}
+	// containment is served by the GIN index on attributes
+	for _, f := range filter.Attributes {
+		add("attributes @> $?::jsonb", containment(f))
+	}
+
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
 func (r *Repository) Update(ctx context.Context, id uuid.UUID, customer *models.Customer) error {
 	const op = "repository.customer.Update"
+	defer metrics.ObserveQuery(op, time.Now())
 
 	query := `
         UPDATE customers
-        SET first_name = $1, last_name = $2, gender = $3, timezone = $4, birthday = $5
-        WHERE id = $6
+        SET first_name = $1, last_name = $2, gender = $3, timezone = $4, birthday = $5, attributes = $6,
+            first_name_enc = $7, last_name_enc = $8, birthday_enc = $9,
+            first_name_bidx = $10, last_name_bidx = $11, birthday_bidx = $12, data_key_id = $13,
+            birthday_md = $14
+        WHERE id = $15 AND erased_at IS NULL
     `
 
-	result, err := r.db.ExecContext(ctx, query,
-		customer.FirstName,
-		customer.LastName,
-		customer.Gender,
-		customer.Timezone,
-		customer.Birthday,
-		id,
-	)
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
 
+	// id is part of the ciphertext associated data
+	sealed := *customer
+	sealed.ID = id
+	row, err := r.codec.Seal(ctx, &sealed)
 	if err != nil {
+		tracing.RecordError(span, err)
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	args := append([]any{row.FirstName, row.LastName, row.Gender, row.Timezone, row.Birthday, row.Attributes},
+		row.EncryptionValues()...)
+	result, err := r.storage.Writer(ctx).ExecContext(ctx, query, append(args, id)...)
+
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
 	}
 
 	if rowsAffected == 0 {
@@ -143,17 +357,26 @@ func (r *Repository) Update(ctx context.Context, id uuid.UUID, customer *models.
 
 func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
 	const op = "repository.customer.Delete"
+	defer metrics.ObserveQuery(op, time.Now())
 
 	query := `DELETE FROM customers WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.storage.Writer(ctx).ExecContext(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
 	}
 
 	if rowsAffected == 0 {
@@ -162,3 +385,504 @@ func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+// openAll decrypts rows into customers.
+func (r *Repository) openAll(ctx context.Context, rows []pii.Row) ([]models.Customer, error) {
+	customers := make([]models.Customer, len(rows))
+	for i := range rows {
+		customer, err := r.codec.Open(ctx, &rows[i])
+		if err != nil {
+			return nil, err
+		}
+		customers[i] = *customer
+	}
+	return customers, nil
+}
+
+// GetByIDs returns existing customers among ids. Rows are read from
+// primary, the batch endpoint updates what it reads.
+func (r *Repository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Customer, error) {
+	const op = "repository.customer.GetByIDs"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT ` + pii.SelectColumns + `
+        FROM customers
+        WHERE id = ANY($1)
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var rows []pii.Row
+	err := r.storage.Writer(ctx).SelectContext(ctx, &rows, query, pq.Array(uuidStrings(ids)))
+
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	customers, err := r.openAll(ctx, rows)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return customers, nil
+}
+
+// GetByBirthdayMonthDays returns up to limit not erased customers with id
+// greater than after whose birthday month and day (MMDD) is one of mds,
+// ordered by id. Customers with an encrypted birthday and no month and
+// day stored are always returned, callers check the birthday itself.
+func (r *Repository) GetByBirthdayMonthDays(ctx context.Context, mds []int16, after uuid.UUID, limit int) ([]models.Customer, error) {
+	const op = "repository.customer.GetByBirthdayMonthDays"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT ` + pii.SelectColumns + `
+        FROM customers
+        WHERE (birthday_md = ANY($1::smallint[]) OR (birthday_md IS NULL AND birthday_enc IS NOT NULL))
+            AND erased_at IS NULL AND id > $2
+        ORDER BY id
+        LIMIT $3
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var rows []pii.Row
+	err := r.storage.Reader(ctx).SelectContext(ctx, &rows, query, pq.Array(mds), after, limit)
+
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	customers, err := r.openAll(ctx, rows)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return customers, nil
+}
+
+// WriteBatch inserts creates and updates existing customers in one
+// transaction with a single statement each.
+func (r *Repository) WriteBatch(ctx context.Context, creates, updates []models.Customer) error {
+	const op = "repository.customer.WriteBatch"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	// ByteaArray cannot hold NULL elements, missing values are sent empty
+	insertQuery := `
+        INSERT INTO customers (id, first_name, last_name, gender, timezone, birthday, user_id, attributes,
+            first_name_enc, last_name_enc, birthday_enc,
+            first_name_bidx, last_name_bidx, birthday_bidx, data_key_id, birthday_md)
+        SELECT id, first_name, last_name, gender, timezone, birthday, user_id, attributes,
+            NULLIF(first_name_enc, '\x'), NULLIF(last_name_enc, '\x'), NULLIF(birthday_enc, '\x'),
+            NULLIF(first_name_bidx, '\x'), NULLIF(last_name_bidx, '\x'), NULLIF(birthday_bidx, '\x'), data_key_id,
+            birthday_md
+        FROM unnest($1::uuid[], $2::varchar[], $3::varchar[], $4::varchar[], $5::varchar[], $6::date[], $7::uuid[],
+            $8::jsonb[], $9::bytea[], $10::bytea[], $11::bytea[], $12::bytea[], $13::bytea[], $14::bytea[], $15::bigint[],
+            $16::smallint[])
+            AS u(id, first_name, last_name, gender, timezone, birthday, user_id, attributes,
+                first_name_enc, last_name_enc, birthday_enc,
+                first_name_bidx, last_name_bidx, birthday_bidx, data_key_id, birthday_md)
+    `
+	updateQuery := `
+        UPDATE customers AS c
+        SET first_name = u.first_name, last_name = u.last_name, gender = u.gender,
+            timezone = u.timezone, birthday = u.birthday, attributes = u.attributes,
+            first_name_enc = NULLIF(u.first_name_enc, '\x'), last_name_enc = NULLIF(u.last_name_enc, '\x'),
+            birthday_enc = NULLIF(u.birthday_enc, '\x'), first_name_bidx = NULLIF(u.first_name_bidx, '\x'),
+            last_name_bidx = NULLIF(u.last_name_bidx, '\x'), birthday_bidx = NULLIF(u.birthday_bidx, '\x'),
+            data_key_id = u.data_key_id, birthday_md = u.birthday_md
+        FROM unnest($1::uuid[], $2::varchar[], $3::varchar[], $4::varchar[], $5::varchar[], $6::date[],
+            $7::jsonb[], $8::bytea[], $9::bytea[], $10::bytea[], $11::bytea[], $12::bytea[], $13::bytea[], $14::bigint[],
+            $15::smallint[])
+            AS u(id, first_name, last_name, gender, timezone, birthday, attributes,
+                first_name_enc, last_name_enc, birthday_enc,
+                first_name_bidx, last_name_bidx, birthday_bidx, data_key_id, birthday_md)
+        WHERE c.id = u.id AND c.erased_at IS NULL
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, insertQuery+";"+updateQuery)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.storage.Writer(ctx).BeginTxx(ctx, nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer tx.Rollback()
+
+	if len(creates) > 0 {
+		c, err := r.columns(ctx, creates)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		_, err = tx.ExecContext(ctx, insertQuery,
+			pq.Array(c.ids), pq.Array(c.firstNames), pq.Array(c.lastNames), pq.Array(c.genders),
+			pq.Array(c.timezones), pq.Array(c.birthdays), pq.Array(c.userIDs), pq.Array(c.attributes),
+			c.firstNamesEnc, c.lastNamesEnc, c.birthdaysEnc,
+			c.firstNamesIdx, c.lastNamesIdx, c.birthdaysIdx, pq.Array(c.dataKeyIDs), pq.Array(c.birthdayMDs),
+		)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+	}
+
+	if len(updates) > 0 {
+		c, err := r.columns(ctx, updates)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		result, err := tx.ExecContext(ctx, updateQuery,
+			pq.Array(c.ids), pq.Array(c.firstNames), pq.Array(c.lastNames), pq.Array(c.genders),
+			pq.Array(c.timezones), pq.Array(c.birthdays), pq.Array(c.attributes),
+			c.firstNamesEnc, c.lastNamesEnc, c.birthdaysEnc,
+			c.firstNamesIdx, c.lastNamesIdx, c.birthdaysIdx, pq.Array(c.dataKeyIDs), pq.Array(c.birthdayMDs),
+		)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+		if rowsAffected != int64(len(updates)) {
+			return storage.ErrUserNotFound
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// Erase irreversibly replaces customer and address PII with placeholders,
+// drops change history, DSAR requests and birthday greetings that still
+// hold or reveal the old values, stores cert and queues
+// EventCustomerErased, all in one transaction. The id is kept so
+// references from other services stay valid.
+func (r *Repository) Erase(ctx context.Context, cert *models.ErasureCertificate) error {
+	const op = "repository.customer.Erase"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	const (
+		lockQuery = `SELECT user_id, erased_at FROM customers WHERE id = $1 FOR UPDATE`
+		// user_id links the customer to the auth user, it is replaced too
+		customerQuery = `
+            UPDATE customers
+            SET first_name = 'erased', last_name = 'erased', birthday = DATE '1900-01-01', attributes = '{}',
+                first_name_enc = NULL, last_name_enc = NULL, birthday_enc = NULL,
+                first_name_bidx = NULL, last_name_bidx = NULL, birthday_bidx = NULL, data_key_id = NULL,
+                birthday_md = NULL, user_id = gen_random_uuid(), erased_at = $2
+            WHERE id = $1
+        `
+		addressesQuery = `
+            UPDATE customer_addresses
+            SET address = 'erased', apartment = '', floor = 0, comments = ''
+            WHERE customer_id = $1
+        `
+		historyQuery   = `DELETE FROM customer_changes WHERE customer_id = $1`
+		dsarQuery      = `DELETE FROM dsar_requests WHERE customer_id = $1`
+		greetingsQuery = `DELETE FROM birthday_greetings WHERE customer_id = $1`
+		certQuery      = `
+            INSERT INTO erasure_certificates (id, customer_id, actor, reason, erased_at)
+            VALUES ($1, $2, $3, $4, $5)
+        `
+	)
+
+	ctx, span := tracing.StartDB(ctx, op, customerQuery)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.storage.Writer(ctx).BeginTxx(ctx, nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer tx.Rollback()
+
+	var (
+		userID   uuid.UUID
+		erasedAt *time.Time
+	)
+	if err := tx.QueryRowxContext(ctx, lockQuery, cert.CustomerID).Scan(&userID, &erasedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrUserNotFound
+		}
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	if erasedAt != nil {
+		return storage.ErrCustomerErased
+	}
+
+	event, err := models.NewEvent(models.EventCustomerErased, cert.CustomerID, models.CustomerErased{
+		CustomerID:    cert.CustomerID,
+		UserID:        userID,
+		CertificateID: cert.ID,
+		ErasedAt:      cert.ErasedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// history is deleted after the update, the trigger records old values
+	steps := []struct {
+		query string
+		args  []any
+	}{
+		{customerQuery, []any{cert.CustomerID, cert.ErasedAt}},
+		{addressesQuery, []any{cert.CustomerID}},
+		{historyQuery, []any{cert.CustomerID}},
+		{dsarQuery, []any{cert.CustomerID}},
+		{greetingsQuery, []any{cert.CustomerID}},
+		{certQuery, []any{cert.ID, cert.CustomerID, cert.Actor, cert.Reason, cert.ErasedAt}},
+	}
+	for _, st := range steps {
+		if _, err := tx.ExecContext(ctx, st.query, st.args...); err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+	}
+
+	if err := outbox.Insert(ctx, tx, event); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// ReencryptBatch rewrites up to limit customers whose PII is not yet
+// under the active data key or whose birthday_md does not follow the
+// codec, and returns the number of rewritten rows. Plaintext values
+// of encrypted columns are removed from their change history as well.
+// Rows locked by concurrent writers are skipped and left for a later batch.
+func (r *Repository) ReencryptBatch(ctx context.Context, limit int) (int, error) {
+	const op = "repository.customer.ReencryptBatch"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	const (
+		selectQuery = `
+            SELECT ` + pii.SelectColumns + `
+            FROM customers
+            WHERE erased_at IS NULL AND (data_key_id IS DISTINCT FROM $1 OR (birthday_md IS NULL) = $3)
+            ORDER BY id
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        `
+		updateQuery = `
+            UPDATE customers
+            SET first_name = $2, last_name = $3, birthday = $4,
+                first_name_enc = $5, last_name_enc = $6, birthday_enc = $7,
+                first_name_bidx = $8, last_name_bidx = $9, birthday_bidx = $10, data_key_id = $11,
+                birthday_md = $12
+            WHERE id = $1
+        `
+		// history recorded before encryption holds the plaintext columns
+		historyQuery = `
+            UPDATE customer_changes ch
+            SET old_data = ch.old_data - s.keys, new_data = ch.new_data - s.keys
+            FROM (
+                SELECT id, array_remove(ARRAY[
+                    CASE WHEN first_name IS NULL THEN 'first_name' END,
+                    CASE WHEN last_name IS NULL THEN 'last_name' END,
+                    CASE WHEN birthday IS NULL THEN 'birthday' END,
+                    CASE WHEN birthday_md IS NULL THEN 'birthday_md' END
+                ], NULL) AS keys
+                FROM customers
+                WHERE id = ANY($1)
+            ) s
+            WHERE ch.customer_id = s.id AND (ch.old_data ?| s.keys OR ch.new_data ?| s.keys)
+        `
+	)
+
+	ctx, span := tracing.StartDB(ctx, op, selectQuery)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	keyID, err := r.codec.ActiveKeyID(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.storage.Writer(ctx).BeginTxx(ctx, nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer tx.Rollback()
+
+	// values do not change, keep the rewrite out of change history
+	if _, err := tx.ExecContext(ctx, `SET LOCAL user_service.reencrypting = 'on'`); err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	var rows []pii.Row
+	if err := tx.SelectContext(ctx, &rows, selectQuery, keyID, limit, r.codec.MonthDayKept()); err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i := range rows {
+		customer, err := r.codec.Open(ctx, &rows[i])
+		if err != nil {
+			tracing.RecordError(span, err)
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		row, err := r.codec.Seal(ctx, customer)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		args := append([]any{row.ID, row.FirstName, row.LastName, row.Birthday}, row.EncryptionValues()...)
+		if _, err := tx.ExecContext(ctx, updateQuery, args...); err != nil {
+			tracing.RecordError(span, err)
+			return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+		ids[i] = customer.ID
+	}
+
+	if _, err := tx.ExecContext(ctx, historyQuery, pq.Array(uuidStrings(ids))); err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return len(rows), nil
+}
+
+func (r *Repository) GetErasureCertificate(ctx context.Context, customerID uuid.UUID) (*models.ErasureCertificate, error) {
+	const op = "repository.customer.GetErasureCertificate"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT id, customer_id, actor, reason, erased_at
+        FROM erasure_certificates
+        WHERE customer_id = $1
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var cert models.ErasureCertificate
+	err := r.storage.Reader(ctx).GetContext(ctx, &cert, query, customerID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrErasureNotFound
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return &cert, nil
+}
+
+type customerColumns struct {
+	ids, genders, timezones, userIDs []string
+	attributes                       []string
+	firstNames, lastNames, birthdays []*string
+	firstNamesEnc, lastNamesEnc      pq.ByteaArray
+	birthdaysEnc, firstNamesIdx      pq.ByteaArray
+	lastNamesIdx, birthdaysIdx       pq.ByteaArray
+	dataKeyIDs                       []*int64
+	birthdayMDs                      []*int16
+}
+
+// columns seals rows and turns them into column arrays for unnest.
+func (r *Repository) columns(ctx context.Context, customers []models.Customer) (customerColumns, error) {
+	var c customerColumns
+	for i := range customers {
+		row, err := r.codec.Seal(ctx, &customers[i])
+		if err != nil {
+			return c, err
+		}
+		var birthday *string
+		if row.Birthday != nil {
+			b := row.Birthday.Format(time.DateOnly)
+			birthday = &b
+		}
+		c.ids = append(c.ids, row.ID.String())
+		c.firstNames = append(c.firstNames, row.FirstName)
+		c.lastNames = append(c.lastNames, row.LastName)
+		c.genders = append(c.genders, row.Gender)
+		c.timezones = append(c.timezones, row.Timezone)
+		c.birthdays = append(c.birthdays, birthday)
+		c.userIDs = append(c.userIDs, row.UserID.String())
+		attributes, err := row.Attributes.Value()
+		if err != nil {
+			return c, err
+		}
+		c.attributes = append(c.attributes, attributes.(string))
+		c.firstNamesEnc = append(c.firstNamesEnc, row.FirstNameEnc)
+		c.lastNamesEnc = append(c.lastNamesEnc, row.LastNameEnc)
+		c.birthdaysEnc = append(c.birthdaysEnc, row.BirthdayEnc)
+		c.firstNamesIdx = append(c.firstNamesIdx, row.FirstNameIdx)
+		c.lastNamesIdx = append(c.lastNamesIdx, row.LastNameIdx)
+		c.birthdaysIdx = append(c.birthdaysIdx, row.BirthdayIdx)
+		c.dataKeyIDs = append(c.dataKeyIDs, row.DataKeyID)
+		c.birthdayMDs = append(c.birthdayMDs, row.BirthdayMD)
+	}
+	return c, nil
+}
+
+// containment returns JSON object with f.Value nested at f.Path.
+func containment(f dto.AttributeFilter) string {
+	v := f.Value
+	for i := len(f.Path) - 1; i >= 0; i-- {
+		v = map[string]any{f.Path[i]: v}
+	}
+	// values are strings, numbers and booleans, marshaling cannot fail
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	res := make([]string, len(ids))
+	for i, id := range ids {
+		res[i] = id.String()
+	}
+	return res
+}
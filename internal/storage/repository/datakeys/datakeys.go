@@ -0,0 +1,110 @@
+package datakeys
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"user-service/internal/lib/envelope"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage/psql"
+)
+
+// Repository stores wrapped data keys, implements envelope.KeyStore.
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout}
+}
+
+func (r *Repository) Latest(ctx context.Context) (*envelope.DataKey, error) {
+	const op = "repository.datakeys.Latest"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT id, master_key_id, wrapped_key, created_at
+        FROM encryption_keys
+        ORDER BY id DESC
+        LIMIT 1
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var key envelope.DataKey
+	err := r.storage.GetDB().GetContext(ctx, &key, query)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, envelope.ErrNoDataKey
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return &key, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id int64) (*envelope.DataKey, error) {
+	const op = "repository.datakeys.Get"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT id, master_key_id, wrapped_key, created_at
+        FROM encryption_keys
+        WHERE id = $1
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var key envelope.DataKey
+	err := r.storage.GetDB().GetContext(ctx, &key, query, id)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w: %d", op, envelope.ErrNoDataKey, id)
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return &key, nil
+}
+
+func (r *Repository) Create(ctx context.Context, masterKeyID string, wrapped []byte) (*envelope.DataKey, error) {
+	const op = "repository.datakeys.Create"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        INSERT INTO encryption_keys (master_key_id, wrapped_key)
+        VALUES ($1, $2)
+        RETURNING id, master_key_id, wrapped_key, created_at
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var key envelope.DataKey
+	if err := r.storage.GetDB().GetContext(ctx, &key, query, masterKeyID, wrapped); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return &key, nil
+}
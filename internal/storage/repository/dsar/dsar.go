@@ -0,0 +1,351 @@
+package dsar
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage"
+	"user-service/internal/storage/pii"
+	"user-service/internal/storage/psql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+	codec        *pii.Codec
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration, codec *pii.Codec) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout, codec: codec}
+}
+
+// Create inserts request, returns storage.ErrUserNotFound if customer does not exist.
+func (r *Repository) Create(ctx context.Context, req *models.DSARRequest) error {
+	const op = "repository.dsar.Create"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        INSERT INTO dsar_requests (id, customer_id, status)
+        VALUES ($1, $2, $3)
+        RETURNING created_at, updated_at
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.storage.Writer(ctx).QueryRowxContext(ctx, query, req.ID, req.CustomerID, req.Status).
+		Scan(&req.CreatedAt, &req.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return storage.ErrUserNotFound
+		}
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// GetByID reads request from primary, status must not lag behind.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*models.DSARRequest, error) {
+	const op = "repository.dsar.GetByID"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT id, customer_id, status, error, created_at, updated_at, finished_at
+        FROM dsar_requests
+        WHERE id = $1
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var req models.DSARRequest
+	err := r.storage.GetDB().GetContext(ctx, &req, query, id)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrDSARNotFound
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return &req, nil
+}
+
+// UpdateStatus saves status, error and finished_at.
+func (r *Repository) UpdateStatus(ctx context.Context, req *models.DSARRequest) error {
+	const op = "repository.dsar.UpdateStatus"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        UPDATE dsar_requests
+        SET status = $2, error = $3, finished_at = $4, updated_at = now()
+        WHERE id = $1
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err := r.storage.Writer(ctx).ExecContext(ctx, query, req.ID, req.Status, req.Error, req.FinishedAt)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// FailUnfinished marks queued and running requests as failed, used on startup
+// because jobs of a previous process are lost.
+func (r *Repository) FailUnfinished(ctx context.Context, reason string) (int64, error) {
+	const op = "repository.dsar.FailUnfinished"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        UPDATE dsar_requests
+        SET status = 'failed', error = $1, finished_at = now(), updated_at = now()
+        WHERE status IN ('queued', 'running')
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.storage.Writer(ctx).ExecContext(ctx, query, reason)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return rowsAffected, nil
+}
+
+// ExpireCompleted marks requests completed before the given time as expired
+// and returns their ids so archives can be removed.
+func (r *Repository) ExpireCompleted(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	const op = "repository.dsar.ExpireCompleted"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        UPDATE dsar_requests
+        SET status = 'expired', updated_at = now()
+        WHERE status = 'completed' AND finished_at < $1
+        RETURNING id
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var ids []uuid.UUID
+	if err := r.storage.Writer(ctx).SelectContext(ctx, &ids, query, before); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return ids, nil
+}
+
+// CollectCustomerData reads customer with all related rows in one
+// repeatable read transaction so the archive is a consistent snapshot.
+func (r *Repository) CollectCustomerData(ctx context.Context, customerID uuid.UUID) (*models.CustomerData, error) {
+	const op = "repository.dsar.CollectCustomerData"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	const (
+		customerQuery = `
+            SELECT ` + pii.SelectColumns + `
+            FROM customers
+            WHERE id = $1
+        `
+		addressesQuery = `
+            SELECT id, address, apartment, floor, comments, customer_id, created_at
+            FROM customer_addresses
+            WHERE customer_id = $1
+            ORDER BY created_at
+        `
+		favoritesQuery = `
+            SELECT product_id, customer_id, created_at
+            FROM favorites
+            WHERE customer_id = $1
+            ORDER BY created_at
+        `
+		changesQuery = `
+            SELECT id, customer_id, operation, old_data, new_data, changed_at
+            FROM customer_changes
+            WHERE customer_id = $1
+            ORDER BY changed_at, id
+        `
+		consentsQuery = `
+            SELECT id, customer_id, purpose, status, source, legal_basis_version, recorded_at
+            FROM consents
+            WHERE customer_id = $1
+            ORDER BY id
+        `
+		tagsQuery = `
+            SELECT tag, created_at
+            FROM customer_tags
+            WHERE customer_id = $1
+            ORDER BY created_at, tag
+        `
+		greetingsQuery = `
+            SELECT customer_id, year, sent_at
+            FROM birthday_greetings
+            WHERE customer_id = $1
+            ORDER BY year
+        `
+		preferencesQuery = `
+            SELECT customer_id, locale, currency, notification_channels,
+                to_char(quiet_hours_start, 'HH24:MI') AS quiet_hours_start,
+                to_char(quiet_hours_end, 'HH24:MI') AS quiet_hours_end,
+                updated_at
+            FROM customer_preferences
+            WHERE customer_id = $1
+        `
+	)
+
+	ctx, span := tracing.StartDB(ctx, op, customerQuery)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.storage.GetDB().BeginTxx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer tx.Rollback()
+
+	data := &models.CustomerData{
+		Addresses: []models.CustomerAddress{},
+		Favorites: []models.Favorite{},
+		Changes:   []models.CustomerChange{},
+		Consents:  []models.Consent{},
+		Tags:      []models.CustomerTag{},
+		Greetings: []models.BirthdayGreeting{},
+	}
+
+	var row pii.Row
+	if err := tx.GetContext(ctx, &row, customerQuery, customerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrUserNotFound
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	customer, err := r.codec.Open(ctx, &row)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	data.Customer = *customer
+
+	if err := tx.SelectContext(ctx, &data.Addresses, addressesQuery, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: addresses: %w", op, psql.ClassifyError(err))
+	}
+	if err := tx.SelectContext(ctx, &data.Favorites, favoritesQuery, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: favorites: %w", op, psql.ClassifyError(err))
+	}
+	if err := tx.SelectContext(ctx, &data.Changes, changesQuery, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: changes: %w", op, psql.ClassifyError(err))
+	}
+	if err := tx.SelectContext(ctx, &data.Consents, consentsQuery, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: consents: %w", op, psql.ClassifyError(err))
+	}
+	if err := tx.SelectContext(ctx, &data.Tags, tagsQuery, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: tags: %w", op, psql.ClassifyError(err))
+	}
+	if err := tx.SelectContext(ctx, &data.Greetings, greetingsQuery, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: birthday greetings: %w", op, psql.ClassifyError(err))
+	}
+	var prefs preferencesRow
+	err = tx.GetContext(ctx, &prefs, preferencesQuery, customerID)
+	switch {
+	case err == nil:
+		data.Preferences = prefs.stored()
+	case !errors.Is(err, sql.ErrNoRows):
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: preferences: %w", op, psql.ClassifyError(err))
+	}
+
+	// history keeps rows as stored, with encrypted columns
+	for i := range data.Changes {
+		ch := &data.Changes[i]
+		for _, d := range []*json.RawMessage{ch.OldData, ch.NewData} {
+			if d == nil {
+				continue
+			}
+			if *d, err = r.codec.OpenJSON(ctx, customerID, *d); err != nil {
+				tracing.RecordError(span, err)
+				return nil, fmt.Errorf("%s: changes: %w", op, err)
+			}
+		}
+	}
+
+	return data, nil
+}
+
+// preferencesRow customer_preferences row, NULL columns use defaults.
+type preferencesRow struct {
+	CustomerID           uuid.UUID      `db:"customer_id"`
+	Locale               *string        `db:"locale"`
+	Currency             *string        `db:"currency"`
+	NotificationChannels pq.StringArray `db:"notification_channels"`
+	QuietHoursStart      *string        `db:"quiet_hours_start"`
+	QuietHoursEnd        *string        `db:"quiet_hours_end"`
+	UpdatedAt            *time.Time     `db:"updated_at"`
+}
+
+func (r preferencesRow) stored() *models.StoredPreferences {
+	return &models.StoredPreferences{
+		CustomerID:           r.CustomerID,
+		Locale:               r.Locale,
+		Currency:             r.Currency,
+		NotificationChannels: r.NotificationChannels,
+		QuietHoursStart:      r.QuietHoursStart,
+		QuietHoursEnd:        r.QuietHoursEnd,
+		UpdatedAt:            r.UpdatedAt,
+	}
+}
@@ -0,0 +1,81 @@
+package genders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage/psql"
+)
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout}
+}
+
+// List returns the enumeration ordered by position. Values are read from
+// primary so a customer write right after a change is checked against it.
+func (r *Repository) List(ctx context.Context) ([]models.Gender, error) {
+	const op = "repository.genders.List"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT code, label, position, active, created_at, updated_at
+        FROM genders
+        ORDER BY position, code
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	genders := []models.Gender{}
+	if err := r.storage.Writer(ctx).SelectContext(ctx, &genders, query); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return genders, nil
+}
+
+// Save adds gender value or replaces label, position and active flag of
+// an existing one.
+func (r *Repository) Save(ctx context.Context, gender *models.Gender) error {
+	const op = "repository.genders.Save"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        INSERT INTO genders (code, label, position, active)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (code) DO UPDATE
+        SET label = EXCLUDED.label,
+            position = EXCLUDED.position,
+            active = EXCLUDED.active,
+            updated_at = CURRENT_TIMESTAMP
+        RETURNING created_at, updated_at
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.storage.Writer(ctx).QueryRowxContext(ctx, query, gender.Code, gender.Label, gender.Position, gender.Active).
+		Scan(&gender.CreatedAt, &gender.UpdatedAt)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
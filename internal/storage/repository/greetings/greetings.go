@@ -0,0 +1,81 @@
+package greetings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage/psql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout}
+}
+
+// Claim records greetings as sent and returns customers whose greeting
+// was not recorded before, only they are to be greeted.
+func (r *Repository) Claim(ctx context.Context, greetings []models.BirthdayGreeting) ([]uuid.UUID, error) {
+	const op = "repository.greetings.Claim"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        INSERT INTO birthday_greetings (customer_id, year)
+        SELECT * FROM unnest($1::uuid[], $2::int[])
+        ON CONFLICT (customer_id, year) DO NOTHING
+        RETURNING customer_id
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	ids := make([]string, len(greetings))
+	years := make([]int64, len(greetings))
+	for i, g := range greetings {
+		ids[i] = g.CustomerID.String()
+		years[i] = int64(g.Year)
+	}
+
+	claimed := []uuid.UUID{}
+	if err := r.storage.Writer(ctx).SelectContext(ctx, &claimed, query, pq.Array(ids), pq.Array(years)); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return claimed, nil
+}
+
+// Unclaim removes greeting recorded by Claim that could not be sent, it
+// is claimed again on the next run.
+func (r *Repository) Unclaim(ctx context.Context, customerID uuid.UUID, year int) error {
+	const op = "repository.greetings.Unclaim"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `DELETE FROM birthday_greetings WHERE customer_id = $1 AND year = $2`
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	if _, err := r.storage.Writer(ctx).ExecContext(ctx, query, customerID, year); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
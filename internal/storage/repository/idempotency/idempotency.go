@@ -0,0 +1,167 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage/psql"
+)
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout}
+}
+
+// Reserve marks key as processing. If key already exists and is neither
+// expired nor an abandoned processing record older than lockTimeout,
+// the stored record is returned and reserved is false.
+func (r *Repository) Reserve(
+	ctx context.Context,
+	client, key, fingerprint string,
+	ttl, lockTimeout time.Duration,
+) (reserved bool, existing *models.IdempotencyKey, err error) {
+	const op = "repository.idempotency.Reserve"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        INSERT INTO idempotency_keys (client, key, fingerprint, status, expires_at)
+        VALUES ($1, $2, $3, 'processing', now() + make_interval(secs => $4))
+        ON CONFLICT (client, key) DO UPDATE
+        SET fingerprint = EXCLUDED.fingerprint,
+            status = 'processing',
+            response_code = NULL,
+            response_content_type = NULL,
+            response_body = NULL,
+            created_at = now(),
+            expires_at = EXCLUDED.expires_at
+        WHERE idempotency_keys.expires_at < now()
+           OR (idempotency_keys.status = 'processing'
+               AND idempotency_keys.created_at < now() - make_interval(secs => $5))
+        RETURNING key
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	db := r.storage.Writer(ctx)
+
+	// the record can be released or purged between the upsert and the
+	// select, the key is free again then and the upsert is retried once
+	for attempt := 0; ; attempt++ {
+		var returned string
+		err = db.GetContext(ctx, &returned, query, client, key, fingerprint, ttl.Seconds(), lockTimeout.Seconds())
+		if err == nil {
+			return true, nil, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			tracing.RecordError(span, err)
+			return false, nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+
+		var record models.IdempotencyKey
+		err = db.GetContext(ctx, &record, `
+            SELECT key, client, fingerprint, status, response_code, response_content_type,
+                   response_body, created_at, expires_at
+            FROM idempotency_keys
+            WHERE client = $1 AND key = $2
+        `, client, key)
+		if errors.Is(err, sql.ErrNoRows) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			tracing.RecordError(span, err)
+			return false, nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+
+		return false, &record, nil
+	}
+}
+
+// Complete stores response for replay.
+func (r *Repository) Complete(ctx context.Context, client, key string, code int, contentType string, body []byte) error {
+	const op = "repository.idempotency.Complete"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        UPDATE idempotency_keys
+        SET status = 'completed', response_code = $3, response_content_type = $4, response_body = $5
+        WHERE client = $1 AND key = $2
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err := r.storage.Writer(ctx).ExecContext(ctx, query, client, key, code, contentType, body)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// Release removes processing key so the request can be retried.
+func (r *Repository) Release(ctx context.Context, client, key string) error {
+	const op = "repository.idempotency.Release"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `DELETE FROM idempotency_keys WHERE client = $1 AND key = $2 AND status = 'processing'`
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err := r.storage.Writer(ctx).ExecContext(ctx, query, client, key)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes expired keys and returns how many were removed.
+func (r *Repository) PurgeExpired(ctx context.Context) (int64, error) {
+	const op = "repository.idempotency.PurgeExpired"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `DELETE FROM idempotency_keys WHERE expires_at < now()`
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.storage.Writer(ctx).ExecContext(ctx, query)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return rowsAffected, nil
+}
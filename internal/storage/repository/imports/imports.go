@@ -0,0 +1,313 @@
+package imports
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage"
+	"user-service/internal/storage/pii"
+	"user-service/internal/storage/psql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+	codec        *pii.Codec
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration, codec *pii.Codec) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout, codec: codec}
+}
+
+func (r *Repository) Create(ctx context.Context, imp *models.Import) error {
+	const op = "repository.imports.Create"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        INSERT INTO imports (id, status, format, dry_run)
+        VALUES ($1, $2, $3, $4)
+        RETURNING created_at, updated_at
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.storage.Writer(ctx).QueryRowxContext(ctx, query, imp.ID, imp.Status, imp.Format, imp.DryRun).
+		Scan(&imp.CreatedAt, &imp.UpdatedAt)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// GetByID reads import from primary, progress must not lag behind.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*models.Import, error) {
+	const op = "repository.imports.GetByID"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT id, status, format, dry_run, total_rows, imported_rows, rejected_rows,
+               error, created_at, updated_at, finished_at
+        FROM imports
+        WHERE id = $1
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var imp models.Import
+	err := r.storage.GetDB().GetContext(ctx, &imp, query, id)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrImportNotFound
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return &imp, nil
+}
+
+// UpdateProgress saves status, counters, error and finished_at.
+func (r *Repository) UpdateProgress(ctx context.Context, imp *models.Import) error {
+	const op = "repository.imports.UpdateProgress"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        UPDATE imports
+        SET status = $2, total_rows = $3, imported_rows = $4, rejected_rows = $5,
+            error = $6, finished_at = $7, updated_at = now()
+        WHERE id = $1
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err := r.storage.Writer(ctx).ExecContext(ctx, query,
+		imp.ID,
+		imp.Status,
+		imp.TotalRows,
+		imp.ImportedRows,
+		imp.RejectedRows,
+		imp.Error,
+		imp.FinishedAt,
+	)
+
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// FailUnfinished marks queued and running imports as failed, used on startup
+// because jobs of a previous process are lost.
+func (r *Repository) FailUnfinished(ctx context.Context, reason string) (int64, error) {
+	const op = "repository.imports.FailUnfinished"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        UPDATE imports
+        SET status = 'failed', error = $1, finished_at = now(), updated_at = now()
+        WHERE status IN ('queued', 'running')
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.storage.Writer(ctx).ExecContext(ctx, query, reason)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return rowsAffected, nil
+}
+
+func (r *Repository) AddRowErrors(ctx context.Context, rowErrors []models.ImportRowError) error {
+	const op = "repository.imports.AddRowErrors"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	if len(rowErrors) == 0 {
+		return nil
+	}
+
+	query := `
+        INSERT INTO import_errors (import_id, row_number, field, error)
+        SELECT * FROM unnest($1::uuid[], $2::int[], $3::text[], $4::text[])
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var (
+		ids     = make([]string, len(rowErrors))
+		numbers = make([]int64, len(rowErrors))
+		fields  = make([]string, len(rowErrors))
+		errs    = make([]string, len(rowErrors))
+	)
+	for i, e := range rowErrors {
+		ids[i] = e.ImportID.String()
+		numbers[i] = int64(e.RowNumber)
+		fields[i] = e.Field
+		errs[i] = e.Error
+	}
+
+	_, err := r.storage.Writer(ctx).ExecContext(ctx, query,
+		pq.Array(ids), pq.Array(numbers), pq.Array(fields), pq.Array(errs))
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// StreamRowErrors calls fn for every rejected row ordered by row number
+// without loading the whole report into memory.
+func (r *Repository) StreamRowErrors(ctx context.Context, importID uuid.UUID, fn func(models.ImportRowError) error) error {
+	const op = "repository.imports.StreamRowErrors"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        SELECT import_id, row_number, field, error
+        FROM import_errors
+        WHERE import_id = $1
+        ORDER BY row_number
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	rows, err := r.storage.GetDB().QueryxContext(ctx, query, importID)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.ImportRowError
+		if err := rows.StructScan(&e); err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// CopyCustomers loads customers with COPY into a temporary staging table
+// and moves them into customers in the same transaction.
+func (r *Repository) CopyCustomers(ctx context.Context, customers []models.Customer) error {
+	const op = "repository.imports.CopyCustomers"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	columns := strings.Join(pii.InsertColumns, ", ")
+
+	query := `
+        INSERT INTO customers (` + columns + `)
+        SELECT ` + columns + `
+        FROM customers_import_staging
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.storage.Writer(ctx).BeginTxx(ctx, nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+        CREATE TEMP TABLE customers_import_staging
+        (LIKE customers INCLUDING DEFAULTS) ON COMMIT DROP
+    `)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: failed to create staging table: %w", op, psql.ClassifyError(err))
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("customers_import_staging", pii.InsertColumns...))
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer stmt.Close()
+
+	for i := range customers {
+		row, err := r.codec.Seal(ctx, &customers[i])
+		if err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if _, err = stmt.ExecContext(ctx, row.Values()...); err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+	}
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: failed to flush copy: %w", op, psql.ClassifyError(err))
+	}
+
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage/psql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout}
+}
+
+// Insert adds event to the outbox using tx of the caller, so the event
+// is stored only if the change it describes is committed.
+func Insert(ctx context.Context, tx sqlx.ExecerContext, event *models.Event) error {
+	const op = "repository.outbox.Insert"
+
+	query := `
+        INSERT INTO outbox_events (id, type, aggregate_id, payload, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+
+	_, err := tx.ExecContext(ctx, query, event.ID, event.Type, event.AggregateID, []byte(event.Payload), event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// PublishPending locks up to limit unpublished events in creation order
+// and passes them to publish. Events are marked published until the first
+// failure, remaining ones are retried on the next call. Replicas relay in
+// parallel and skip each other's locked rows, so consumers must not rely
+// on event order. Returns the number of published events.
+func (r *Repository) PublishPending(ctx context.Context, limit int, publish func(models.Event) error) (int, error) {
+	const op = "repository.outbox.PublishPending"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	selectQuery := `
+        SELECT id, type, aggregate_id, payload, created_at
+        FROM outbox_events
+        WHERE published_at IS NULL
+        ORDER BY created_at, id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `
+	publishedQuery := `UPDATE outbox_events SET published_at = now(), attempts = attempts + 1 WHERE id = $1`
+	failedQuery := `UPDATE outbox_events SET attempts = attempts + 1, last_error = $2 WHERE id = $1`
+
+	ctx, span := tracing.StartDB(ctx, op, selectQuery)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.storage.GetDB().BeginTxx(ctx, nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer tx.Rollback()
+
+	var events []models.Event
+	if err := tx.SelectContext(ctx, &events, selectQuery, limit); err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	published := 0
+	for _, e := range events {
+		if err := publish(e); err != nil {
+			if _, dbErr := tx.ExecContext(ctx, failedQuery, e.ID, err.Error()); dbErr != nil {
+				tracing.RecordError(span, dbErr)
+				return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(dbErr))
+			}
+			break
+		}
+		if _, err := tx.ExecContext(ctx, publishedQuery, e.ID); err != nil {
+			tracing.RecordError(span, err)
+			return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+		}
+		published++
+	}
+
+	if err := tx.Commit(); err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return published, nil
+}
+
+// PurgePublished deletes events published before the given time.
+func (r *Repository) PurgePublished(ctx context.Context, before time.Time) (int64, error) {
+	const op = "repository.outbox.PurgePublished"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `DELETE FROM outbox_events WHERE published_at < $1`
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.storage.Writer(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,178 @@
+package preferences
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage"
+	"user-service/internal/storage/psql"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// selectQuery joins customers so a customer without stored preferences
+// is found too, with the timezone quiet hours are in.
+const selectQuery = `
+        SELECT c.id AS customer_id, c.timezone, p.locale, p.currency, p.notification_channels,
+            to_char(p.quiet_hours_start, 'HH24:MI') AS quiet_hours_start,
+            to_char(p.quiet_hours_end, 'HH24:MI') AS quiet_hours_end,
+            p.updated_at
+        FROM customers c
+        LEFT JOIN customer_preferences p ON p.customer_id = c.id
+    `
+
+type row struct {
+	CustomerID           uuid.UUID      `db:"customer_id"`
+	Timezone             string         `db:"timezone"`
+	Locale               *string        `db:"locale"`
+	Currency             *string        `db:"currency"`
+	NotificationChannels pq.StringArray `db:"notification_channels"`
+	QuietHoursStart      *string        `db:"quiet_hours_start"`
+	QuietHoursEnd        *string        `db:"quiet_hours_end"`
+	UpdatedAt            *time.Time     `db:"updated_at"`
+}
+
+func (r row) stored() models.StoredPreferences {
+	return models.StoredPreferences{
+		CustomerID:           r.CustomerID,
+		Timezone:             r.Timezone,
+		Locale:               r.Locale,
+		Currency:             r.Currency,
+		NotificationChannels: r.NotificationChannels,
+		QuietHoursStart:      r.QuietHoursStart,
+		QuietHoursEnd:        r.QuietHoursEnd,
+		UpdatedAt:            r.UpdatedAt,
+	}
+}
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout}
+}
+
+func (r *Repository) Get(ctx context.Context, customerID uuid.UUID) (*models.StoredPreferences, error) {
+	const op = "repository.preferences.Get"
+	return r.get(ctx, op, customerID, r.storage.Reader)
+}
+
+// GetForWrite is Get read from primary, used before Save so a customer
+// just created is found and replica lag does not hide a newer row.
+func (r *Repository) GetForWrite(ctx context.Context, customerID uuid.UUID) (*models.StoredPreferences, error) {
+	const op = "repository.preferences.GetForWrite"
+	return r.get(ctx, op, customerID, r.storage.Writer)
+}
+
+func (r *Repository) get(ctx context.Context, op string, customerID uuid.UUID, db func(context.Context) *sqlx.DB) (*models.StoredPreferences, error) {
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := selectQuery + `WHERE c.id = $1`
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var res row
+	err := db(ctx).GetContext(ctx, &res, query, customerID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrUserNotFound
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	prefs := res.stored()
+	return &prefs, nil
+}
+
+// GetMany returns preferences of existing customers among ids.
+func (r *Repository) GetMany(ctx context.Context, ids []uuid.UUID) ([]models.StoredPreferences, error) {
+	const op = "repository.preferences.GetMany"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := selectQuery + `WHERE c.id = ANY($1)`
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	strIDs := make([]string, len(ids))
+	for i, id := range ids {
+		strIDs[i] = id.String()
+	}
+
+	var rows []row
+	if err := r.storage.Reader(ctx).SelectContext(ctx, &rows, query, pq.Array(strIDs)); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	res := make([]models.StoredPreferences, len(rows))
+	for i, rw := range rows {
+		res[i] = rw.stored()
+	}
+	return res, nil
+}
+
+// Save replaces preferences of customer and sets prefs.UpdatedAt,
+// returns storage.ErrUserNotFound if customer does not exist.
+func (r *Repository) Save(ctx context.Context, prefs *models.StoredPreferences) error {
+	const op = "repository.preferences.Save"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        INSERT INTO customer_preferences
+            (customer_id, locale, currency, notification_channels, quiet_hours_start, quiet_hours_end, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, now())
+        ON CONFLICT (customer_id) DO UPDATE
+        SET locale = EXCLUDED.locale, currency = EXCLUDED.currency,
+            notification_channels = EXCLUDED.notification_channels,
+            quiet_hours_start = EXCLUDED.quiet_hours_start, quiet_hours_end = EXCLUDED.quiet_hours_end,
+            updated_at = EXCLUDED.updated_at
+        RETURNING updated_at
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var updatedAt time.Time
+	err := r.storage.Writer(ctx).QueryRowxContext(ctx, query,
+		prefs.CustomerID,
+		prefs.Locale,
+		prefs.Currency,
+		pq.StringArray(prefs.NotificationChannels),
+		prefs.QuietHoursStart,
+		prefs.QuietHoursEnd,
+	).Scan(&updatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return storage.ErrUserNotFound
+		}
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	prefs.UpdatedAt = &updatedAt
+	return nil
+}
@@ -0,0 +1,172 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"user-service/internal/domain/dto"
+	"user-service/internal/domain/models"
+	"user-service/internal/storage"
+	"user-service/internal/storage/pii"
+
+	"github.com/lib/pq"
+)
+
+// compiler turns segment rules into a SQL condition on customers
+// aliased c. Values are passed as positional arguments after the ones
+// already in args.
+type compiler struct {
+	codec *pii.Codec
+	args  []any
+}
+
+func (c *compiler) arg(v any) string {
+	c.args = append(c.args, v)
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+func (c *compiler) compile(rule *models.SegmentRule) (string, error) {
+	switch {
+	case rule.All != nil:
+		return c.join(rule.All, " AND ")
+	case rule.Any != nil:
+		return c.join(rule.Any, " OR ")
+	case rule.Not != nil:
+		cond, err := c.compile(rule.Not)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + cond, nil
+	}
+	return c.condition(rule)
+}
+
+func (c *compiler) join(rules []models.SegmentRule, op string) (string, error) {
+	conds := make([]string, len(rules))
+	for i := range rules {
+		cond, err := c.compile(&rules[i])
+		if err != nil {
+			return "", err
+		}
+		conds[i] = cond
+	}
+	return "(" + strings.Join(conds, op) + ")", nil
+}
+
+func (c *compiler) condition(rule *models.SegmentRule) (string, error) {
+	switch rule.Field {
+	case models.SegmentFieldGender, models.SegmentFieldTimezone:
+		column := "c." + rule.Field
+		if rule.Op == models.SegmentOpIn {
+			var list []string
+			if err := json.Unmarshal(rule.Value, &list); err != nil {
+				return "", invalid(rule, err)
+			}
+			return column + " = ANY(" + c.arg(pq.StringArray(list)) + ")", nil
+		}
+		var s string
+		if err := json.Unmarshal(rule.Value, &s); err != nil {
+			return "", invalid(rule, err)
+		}
+		return column + " = " + c.arg(s), nil
+
+	case models.SegmentFieldAge:
+		if c.codec.Encrypted(pii.Birthday) {
+			return "", fmt.Errorf("%w: age rules need plaintext birthday, it is encrypted", storage.ErrUnsupportedQuery)
+		}
+		// age >= n means born n years ago or earlier, age <= n means
+		// born less than n+1 years ago
+		born := func(years int) string {
+			return "CURRENT_DATE - make_interval(years => " + c.arg(years) + ")"
+		}
+		return c.numeric(rule, func(op string, n int) string {
+			switch op {
+			case models.SegmentOpGte:
+				return "c.birthday <= " + born(n)
+			default:
+				return "c.birthday > " + born(n+1)
+			}
+		})
+
+	case models.SegmentFieldFavoritesCount:
+		count := "(SELECT count(*) FROM favorites f WHERE f.customer_id = c.id)"
+		return c.numeric(rule, func(op string, n int) string {
+			switch op {
+			case models.SegmentOpEq:
+				return count + " = " + c.arg(n)
+			case models.SegmentOpGte:
+				return count + " >= " + c.arg(n)
+			default:
+				return count + " <= " + c.arg(n)
+			}
+		})
+
+	case models.SegmentFieldCreatedAt:
+		if rule.Op == models.SegmentOpBetween {
+			var r []time.Time
+			if err := json.Unmarshal(rule.Value, &r); err != nil || len(r) != 2 {
+				return "", invalid(rule, err)
+			}
+			return "(c.created_at >= " + c.arg(r[0]) + " AND c.created_at < " + c.arg(r[1]) + ")", nil
+		}
+		var t time.Time
+		if err := json.Unmarshal(rule.Value, &t); err != nil {
+			return "", invalid(rule, err)
+		}
+		if rule.Op == models.SegmentOpGte {
+			return "c.created_at >= " + c.arg(t), nil
+		}
+		return "c.created_at < " + c.arg(t), nil
+
+	case models.SegmentFieldAttributes:
+		// containment is served by the GIN index on attributes
+		var v any = rule.Value
+		for i := len(rule.Path) - 1; i >= 0; i-- {
+			v = map[string]any{rule.Path[i]: v}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", invalid(rule, err)
+		}
+		return "c.attributes @> " + c.arg(string(b)) + "::jsonb", nil
+
+	case models.SegmentFieldTag:
+		var tag string
+		if err := json.Unmarshal(rule.Value, &tag); err != nil {
+			return "", invalid(rule, err)
+		}
+		// segments saved before values were normalized on validation
+		tag, err := dto.NormalizeTag(tag)
+		if err != nil {
+			return "", invalid(rule, err)
+		}
+		return "EXISTS (SELECT 1 FROM customer_tags t WHERE t.customer_id = c.id AND t.tag = " + c.arg(tag) + ")", nil
+	}
+
+	return "", fmt.Errorf("segment rule: unknown field %s", rule.Field)
+}
+
+// numeric compiles integer comparison, between is turned into gte and lte.
+func (c *compiler) numeric(rule *models.SegmentRule, cond func(op string, n int) string) (string, error) {
+	if rule.Op == models.SegmentOpBetween {
+		var r []int
+		if err := json.Unmarshal(rule.Value, &r); err != nil || len(r) != 2 {
+			return "", invalid(rule, err)
+		}
+		return "(" + cond(models.SegmentOpGte, r[0]) + " AND " + cond(models.SegmentOpLte, r[1]) + ")", nil
+	}
+	var n int
+	if err := json.Unmarshal(rule.Value, &n); err != nil {
+		return "", invalid(rule, err)
+	}
+	return cond(rule.Op, n), nil
+}
+
+func invalid(rule *models.SegmentRule, err error) error {
+	if err == nil {
+		err = fmt.Errorf("unexpected value %s", rule.Value)
+	}
+	return fmt.Errorf("segment rule %s %s: %w", rule.Field, rule.Op, err)
+}
@@ -0,0 +1,184 @@
+package segment
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/envelope"
+	"user-service/internal/storage"
+	"user-service/internal/storage/pii"
+
+	"github.com/lib/pq"
+)
+
+func mustCodec(t *testing.T, encrypted ...string) *pii.Codec {
+	t.Helper()
+	var cipher *envelope.Cipher
+	if len(encrypted) > 0 {
+		kms, err := envelope.NewStubKMS("test-seed-0123456789", "v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cipher = envelope.New(kms, nil, time.Minute)
+	}
+	codec, err := pii.NewCodec(cipher, encrypted, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return codec
+}
+
+func TestCompile(t *testing.T) {
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rule     string
+		want     string
+		wantArgs []any
+	}{
+		{
+			name:     "gender eq",
+			rule:     `{"field": "gender", "op": "eq", "value": "female"}`,
+			want:     "c.gender = $1",
+			wantArgs: []any{"female"},
+		},
+		{
+			name:     "timezone in",
+			rule:     `{"field": "timezone", "op": "in", "value": ["Europe/Berlin", "UTC"]}`,
+			want:     "c.timezone = ANY($1)",
+			wantArgs: []any{pq.StringArray{"Europe/Berlin", "UTC"}},
+		},
+		{
+			name:     "age gte",
+			rule:     `{"field": "age", "op": "gte", "value": 18}`,
+			want:     "c.birthday <= CURRENT_DATE - make_interval(years => $1)",
+			wantArgs: []any{18},
+		},
+		{
+			name:     "age lte",
+			rule:     `{"field": "age", "op": "lte", "value": 30}`,
+			want:     "c.birthday > CURRENT_DATE - make_interval(years => $1)",
+			wantArgs: []any{31},
+		},
+		{
+			name:     "age between",
+			rule:     `{"field": "age", "op": "between", "value": [18, 30]}`,
+			want:     "(c.birthday <= CURRENT_DATE - make_interval(years => $1) AND c.birthday > CURRENT_DATE - make_interval(years => $2))",
+			wantArgs: []any{18, 31},
+		},
+		{
+			name:     "favorites count eq",
+			rule:     `{"field": "favorites_count", "op": "eq", "value": 0}`,
+			want:     "(SELECT count(*) FROM favorites f WHERE f.customer_id = c.id) = $1",
+			wantArgs: []any{0},
+		},
+		{
+			name:     "created at between",
+			rule:     `{"field": "created_at", "op": "between", "value": ["2024-01-01T00:00:00Z", "2024-02-01T00:00:00Z"]}`,
+			want:     "(c.created_at >= $1 AND c.created_at < $2)",
+			wantArgs: []any{jan, feb},
+		},
+		{
+			name:     "created at lt",
+			rule:     `{"field": "created_at", "op": "lt", "value": "2024-01-01T00:00:00Z"}`,
+			want:     "c.created_at < $1",
+			wantArgs: []any{jan},
+		},
+		{
+			name:     "attribute path",
+			rule:     `{"field": "attributes", "path": ["loyalty", "tier"], "op": "eq", "value": "gold"}`,
+			want:     "c.attributes @> $1::jsonb",
+			wantArgs: []any{`{"loyalty":{"tier":"gold"}}`},
+		},
+		{
+			name:     "tag normalized",
+			rule:     `{"field": "tag", "op": "eq", "value": " VIP "}`,
+			want:     "EXISTS (SELECT 1 FROM customer_tags t WHERE t.customer_id = c.id AND t.tag = $1)",
+			wantArgs: []any{"vip"},
+		},
+		{
+			name: "nested groups number args in order",
+			rule: `{"all": [
+				{"field": "gender", "op": "eq", "value": "male"},
+				{"any": [
+					{"field": "tag", "op": "eq", "value": "vip"},
+					{"not": {"field": "favorites_count", "op": "gte", "value": 5}}
+				]}
+			]}`,
+			want: "(c.gender = $1 AND (EXISTS (SELECT 1 FROM customer_tags t WHERE t.customer_id = c.id AND t.tag = $2)" +
+				" OR NOT (SELECT count(*) FROM favorites f WHERE f.customer_id = c.id) >= $3))",
+			wantArgs: []any{"male", "vip", 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rule models.SegmentRule
+			if err := json.Unmarshal([]byte(tt.rule), &rule); err != nil {
+				t.Fatal(err)
+			}
+			c := &compiler{codec: mustCodec(t)}
+			got, err := c.compile(&rule)
+			if err != nil {
+				t.Fatalf("compile() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("compile() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(c.args, tt.wantArgs) {
+				t.Fatalf("compile() args = %#v, want %#v", c.args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestCompileArgsOffset(t *testing.T) {
+	c := &compiler{codec: mustCodec(t), args: []any{"before"}}
+	got, err := c.compile(&models.SegmentRule{Field: models.SegmentFieldGender, Op: models.SegmentOpEq, Value: json.RawMessage(`"female"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "c.gender = $2" {
+		t.Fatalf("compile() = %q, want placeholder after existing args", got)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        string
+		encrypted   []string
+		unsupported bool
+	}{
+		{"unknown field", `{"field": "email", "op": "eq", "value": "a@b.io"}`, nil, false},
+		{"gender not string", `{"field": "gender", "op": "eq", "value": 1}`, nil, false},
+		{"between one value", `{"field": "age", "op": "between", "value": [18]}`, nil, false},
+		{"created at not time", `{"field": "created_at", "op": "gte", "value": "yesterday"}`, nil, false},
+		{"invalid tag", `{"field": "tag", "op": "eq", "value": "no spaces allowed"}`, nil, false},
+		{"error inside group", `{"all": [{"field": "gender", "op": "eq", "value": "male"}, {"field": "email", "op": "eq", "value": "x"}]}`, nil, false},
+		{"age on encrypted birthday", `{"field": "age", "op": "gte", "value": 18}`, []string{pii.Birthday}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rule models.SegmentRule
+			if err := json.Unmarshal([]byte(tt.rule), &rule); err != nil {
+				t.Fatal(err)
+			}
+			c := &compiler{codec: mustCodec(t, tt.encrypted...)}
+			_, err := c.compile(&rule)
+			if err == nil {
+				t.Fatal("compile() error = nil, want error")
+			}
+			if errors.Is(err, storage.ErrUnsupportedQuery) != tt.unsupported {
+				t.Fatalf("compile() error = %v, unsupported query %v", err, tt.unsupported)
+			}
+		})
+	}
+}
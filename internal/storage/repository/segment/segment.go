@@ -0,0 +1,302 @@
+package segment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"user-service/internal/domain/models"
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage"
+	"user-service/internal/storage/pii"
+	"user-service/internal/storage/psql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const selectColumns = `id, name, rules, created_at, updated_at`
+
+type row struct {
+	ID        uuid.UUID `db:"id"`
+	Name      string    `db:"name"`
+	Rules     []byte    `db:"rules"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (r *row) segment() (*models.Segment, error) {
+	s := &models.Segment{ID: r.ID, Name: r.Name, CreatedAt: r.CreatedAt, UpdatedAt: r.UpdatedAt}
+	if err := json.Unmarshal(r.Rules, &s.Rules); err != nil {
+		return nil, fmt.Errorf("segment %s: invalid rules: %w", r.ID, err)
+	}
+	return s, nil
+}
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+	codec        *pii.Codec
+}
+
+// New creates repository, codec decrypts customers of segments and
+// tells which rules cannot run against encrypted columns.
+func New(storage *psql.Storage, queryTimeout time.Duration, codec *pii.Codec) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout, codec: codec}
+}
+
+// Create stores segment, fills timestamps.
+func (r *Repository) Create(ctx context.Context, segment *models.Segment) error {
+	const op = "repository.segment.Create"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        INSERT INTO segments (id, name, rules)
+        VALUES ($1, $2, $3)
+        RETURNING created_at, updated_at
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rules, err := json.Marshal(segment.Rules)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.storage.Writer(ctx).QueryRowxContext(ctx, query, segment.ID, segment.Name, string(rules)).
+		Scan(&segment.CreatedAt, &segment.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrSegmentExists
+		}
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+// Update replaces name and rules, fills timestamps.
+func (r *Repository) Update(ctx context.Context, segment *models.Segment) error {
+	const op = "repository.segment.Update"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `
+        UPDATE segments
+        SET name = $2, rules = $3, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+        RETURNING created_at, updated_at
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rules, err := json.Marshal(segment.Rules)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.storage.Writer(ctx).QueryRowxContext(ctx, query, segment.ID, segment.Name, string(rules)).
+		Scan(&segment.CreatedAt, &segment.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return storage.ErrSegmentNotFound
+		case isUniqueViolation(err):
+			return storage.ErrSegmentExists
+		}
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.segment.Delete"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `DELETE FROM segments WHERE id = $1`
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.storage.Writer(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tracing.RecordError(span, err)
+		return fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	if rowsAffected == 0 {
+		return storage.ErrSegmentNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) Get(ctx context.Context, id uuid.UUID) (*models.Segment, error) {
+	const op = "repository.segment.Get"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `SELECT ` + selectColumns + ` FROM segments WHERE id = $1`
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var sr row
+	if err := r.storage.Reader(ctx).GetContext(ctx, &sr, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrSegmentNotFound
+		}
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	segment, err := sr.segment()
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return segment, nil
+}
+
+// List returns all segments ordered by name.
+func (r *Repository) List(ctx context.Context) ([]models.Segment, error) {
+	const op = "repository.segment.List"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	query := `SELECT ` + selectColumns + ` FROM segments ORDER BY name`
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var rows []row
+	if err := r.storage.Reader(ctx).SelectContext(ctx, &rows, query); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	segments := make([]models.Segment, 0, len(rows))
+	for i := range rows {
+		segment, err := rows[i].segment()
+		if err != nil {
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		segments = append(segments, *segment)
+	}
+
+	return segments, nil
+}
+
+// Customers returns customers matching rule with id greater than after,
+// ordered by id. Erased customers are left out.
+func (r *Repository) Customers(ctx context.Context, rule *models.SegmentRule, after uuid.UUID, limit int) ([]models.Customer, error) {
+	const op = "repository.segment.Customers"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	c := &compiler{codec: r.codec, args: []any{after, limit}}
+	cond, err := c.compile(rule)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+        SELECT ` + pii.SelectColumns + `
+        FROM customers c
+        WHERE c.erased_at IS NULL AND c.id > $1 AND ` + cond + `
+        ORDER BY c.id
+        LIMIT $2
+    `
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var rows []pii.Row
+	if err := r.storage.Reader(ctx).SelectContext(ctx, &rows, query, c.args...); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	customers := make([]models.Customer, 0, len(rows))
+	for i := range rows {
+		customer, err := r.codec.Open(ctx, &rows[i])
+		if err != nil {
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		customers = append(customers, *customer)
+	}
+
+	return customers, nil
+}
+
+// Count returns number of not erased customers matching rule.
+func (r *Repository) Count(ctx context.Context, rule *models.SegmentRule) (int64, error) {
+	const op = "repository.segment.Count"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	c := &compiler{codec: r.codec}
+	cond, err := c.compile(rule)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `SELECT count(*) FROM customers c WHERE c.erased_at IS NULL AND ` + cond
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var count int64
+	if err := r.storage.Reader(ctx).GetContext(ctx, &count, query, c.args...); err != nil {
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return count, nil
+}
+
+// Check reports whether rule can be compiled, returns
+// storage.ErrUnsupportedQuery for rules on encrypted columns.
+func (r *Repository) Check(rule *models.SegmentRule) error {
+	c := &compiler{codec: r.codec}
+	_, err := c.compile(rule)
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,143 @@
+package tags
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"user-service/internal/lib/metrics"
+	"user-service/internal/lib/tracing"
+	"user-service/internal/storage"
+	"user-service/internal/storage/psql"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const listQuery = `SELECT tag FROM customer_tags WHERE customer_id = $1 ORDER BY tag`
+
+type Repository struct {
+	storage      *psql.Storage
+	queryTimeout time.Duration
+}
+
+func New(storage *psql.Storage, queryTimeout time.Duration) *Repository {
+	return &Repository{storage: storage, queryTimeout: queryTimeout}
+}
+
+// List returns tags of customer ordered by name, storage.ErrUserNotFound
+// if customer does not exist.
+func (r *Repository) List(ctx context.Context, customerID uuid.UUID) ([]string, error) {
+	const op = "repository.tags.List"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.StartDB(ctx, op, listQuery)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	db := r.storage.Reader(ctx)
+
+	var exists bool
+	if err := db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1)`, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	if !exists {
+		return nil, storage.ErrUserNotFound
+	}
+
+	tags := []string{}
+	if err := db.SelectContext(ctx, &tags, listQuery, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return tags, nil
+}
+
+// Add tags customer, tags it already has are kept. Returns all tags of
+// customer.
+func (r *Repository) Add(ctx context.Context, customerID uuid.UUID, tags []string) ([]string, error) {
+	const op = "repository.tags.Add"
+
+	query := `
+        INSERT INTO customer_tags (customer_id, tag)
+        SELECT $1, unnest($2::varchar[])
+        ON CONFLICT DO NOTHING
+    `
+
+	return r.write(ctx, op, customerID, query, pq.StringArray(tags))
+}
+
+// Remove removes tag from customer, missing tag is not an error. Returns
+// remaining tags of customer.
+func (r *Repository) Remove(ctx context.Context, customerID uuid.UUID, tag string) ([]string, error) {
+	const op = "repository.tags.Remove"
+
+	query := `DELETE FROM customer_tags WHERE customer_id = $1 AND tag = $2`
+
+	return r.write(ctx, op, customerID, query, tag)
+}
+
+// write runs query with customer id and arg in a transaction with the
+// customer row locked, erased customers are not changed.
+func (r *Repository) write(ctx context.Context, op string, customerID uuid.UUID, query string, arg any) ([]string, error) {
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, span := tracing.StartDB(ctx, op, query)
+	defer span.End()
+
+	ctx, cancel := psql.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.storage.Writer(ctx).BeginTxx(ctx, nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+	defer tx.Rollback()
+
+	if err := lockCustomer(ctx, tx, customerID); err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) && !errors.Is(err, storage.ErrCustomerErased) {
+			tracing.RecordError(span, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, customerID, arg); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	tags := []string{}
+	if err := tx.SelectContext(ctx, &tags, listQuery, customerID); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, psql.ClassifyError(err))
+	}
+
+	return tags, nil
+}
+
+func lockCustomer(ctx context.Context, tx *sqlx.Tx, customerID uuid.UUID) error {
+	var erasedAt *time.Time
+	err := tx.QueryRowxContext(ctx, `SELECT erased_at FROM customers WHERE id = $1 FOR UPDATE`, customerID).Scan(&erasedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return storage.ErrUserNotFound
+	case err != nil:
+		return psql.ClassifyError(err)
+	case erasedAt != nil:
+		return storage.ErrCustomerErased
+	}
+	return nil
+}
@@ -3,10 +3,22 @@ package storage
 import "errors"
 
 var (
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserAlreadyExist   = errors.New("user already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrCodeBlocked        = errors.New("too many attempts, try again later")
-	ErrCodeInvalid        = errors.New("invalid code")
-	ErrCodeNotFound       = errors.New("code not found or expired")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrUserAlreadyExist        = errors.New("user already exists")
+	ErrInvalidCredentials      = errors.New("invalid credentials")
+	ErrCodeBlocked             = errors.New("too many attempts, try again later")
+	ErrCodeInvalid             = errors.New("invalid code")
+	ErrCodeNotFound            = errors.New("code not found or expired")
+	ErrImportNotFound          = errors.New("import not found")
+	ErrDSARNotFound            = errors.New("dsar request not found")
+	ErrCustomerErased          = errors.New("customer is erased")
+	ErrErasureNotFound         = errors.New("erasure certificate not found")
+	ErrAttributeSchemaNotFound = errors.New("attribute schema not found")
+	ErrSegmentNotFound         = errors.New("segment not found")
+	ErrSegmentExists           = errors.New("segment already exists")
+	// ErrUnsupportedQuery query cannot be answered with the current
+	// storage layout, e.g. a filter on an encrypted column.
+	ErrUnsupportedQuery = errors.New("query is not supported")
+	ErrQueryTimeout     = errors.New("query timeout")
+	ErrUnavailable      = errors.New("storage unavailable")
 )